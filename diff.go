@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// diffLine is a line of a diff: unchanged (' '), deleted ('-') or inserted
+// ('+'). a and b are the numbers of lines of the old and new text that
+// precede it.
+type diffLine struct {
+	op   byte
+	text string
+	a, b int
+}
+
+// unifiedDiff returns the differences between old and new in the unified
+// format, or the empty string if there are none.
+func unifiedDiff(oldName, newName string, old, new []byte) string {
+	lines := diffLines(splitLines(string(old)), splitLines(string(new)))
+
+	var b strings.Builder
+	for i := 0; i < len(lines); {
+		// Find the next change and the extent of its hunk, which includes
+		// the changes that are close enough to share context lines.
+		for i < len(lines) && lines[i].op == ' ' {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(lines))
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		var oldLen, newLen int
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				oldLen++
+			}
+			if l.op != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(lines[start].a, oldLen), hunkRange(lines[start].b, newLen))
+		for _, l := range lines[start:end] {
+			b.WriteByte(l.op)
+			b.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the range of lines of a hunk that starts after the given
+// number of lines.
+func hunkRange(before, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if n == 1 {
+		return fmt.Sprint(before + 1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, n)
+}
+
+// splitLines splits s into lines, each with its line terminator.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script that turns a into b, computed
+// with the linear space variant of Myers' algorithm: the middle snake of the
+// shortest path splits the texts, whose halves are diffed in turn.
+func diffLines(a, b []string) []diffLine {
+	var lines []diffLine
+	same := func(x, y int) {
+		lines = append(lines, diffLine{op: ' ', text: a[x], a: x, b: y})
+	}
+	var walk func(x0, x1, y0, y1 int)
+	walk = func(x0, x1, y0, y1 int) {
+		for x0 < x1 && y0 < y1 && a[x0] == b[y0] {
+			same(x0, y0)
+			x0, y0 = x0+1, y0+1
+		}
+		suffix := 0
+		for x1-suffix > x0 && y1-suffix > y0 && a[x1-suffix-1] == b[y1-suffix-1] {
+			suffix++
+		}
+		x1, y1 = x1-suffix, y1-suffix
+
+		switch {
+		case x0 == x1:
+			for y := y0; y < y1; y++ {
+				lines = append(lines, diffLine{op: '+', text: b[y], a: x0, b: y})
+			}
+		case y0 == y1:
+			for x := x0; x < x1; x++ {
+				lines = append(lines, diffLine{op: '-', text: a[x], a: x, b: y0})
+			}
+		default:
+			x, y, u, v := middleSnake(a[x0:x1], b[y0:y1])
+			walk(x0, x0+x, y0, y0+y)
+			for i := range u - x {
+				same(x0+x+i, y0+y+i)
+			}
+			walk(x0+u, x1, y0+v, y1)
+		}
+
+		for i := range suffix {
+			same(x1+i, y1+i)
+		}
+	}
+	walk(0, len(a), 0, len(b))
+	return lines
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the diagonal run of
+// equal lines in the middle of a shortest edit script that turns a into b,
+// found by searching from both ends at once. The edits before the run and
+// those after it are each fewer than those of the whole script if a and b
+// differ in their first and last lines.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	offset := (n+m+1)/2 + 1
+	// forward holds the furthest x reached on each diagonal k = x-y from the
+	// start, and backward the furthest distance from the end reached on each
+	// diagonal counted from the end.
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+	for d := 0; d < offset; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || k != d && forward[offset+k-1] < forward[offset+k+1] {
+				x0 = forward[offset+k+1]
+			} else {
+				x0 = forward[offset+k-1] + 1
+			}
+			x, y := x0, x0-k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			forward[offset+k] = x
+			if rk := delta - k; delta%2 != 0 && -d < rk && rk < d && x+backward[offset+rk] >= n {
+				return x0, x0 - k, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || k != d && backward[offset+k-1] < backward[offset+k+1] {
+				x0 = backward[offset+k+1]
+			} else {
+				x0 = backward[offset+k-1] + 1
+			}
+			x, y := x0, x0-k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x, y = x+1, y+1
+			}
+			backward[offset+k] = x
+			if fk := delta - k; delta%2 == 0 && -d <= fk && fk <= d && forward[offset+fk]+x >= n {
+				return n - x, m - y, n - x0, m - (x0 - k)
+			}
+		}
+	}
+	panic("unreachable")
+}
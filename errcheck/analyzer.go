@@ -1,77 +1,348 @@
 package errcheck
 
 import (
+	"flag"
 	"fmt"
 	"go/ast"
+	"os"
 	"reflect"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/tools/go/analysis"
 )
 
-var Analyzer = &analysis.Analyzer{
-	Name:       "errcheck",
-	Doc:        "check for unchecked errors",
-	Run:        runAnalyzer,
-	ResultType: reflect.TypeOf(Result{}),
+// Analyzer checks packages for unchecked errors. It is configured by its
+// flags; use NewAnalyzer to create analyzers that are configured by an
+// Exclusions value instead.
+var Analyzer = NewFlagAnalyzer()
+
+// NewAnalyzer returns an analyzer that checks packages with the given
+// exclusions. Unlike Analyzer, it has no flags and reads no files: the
+// exclusions are prepared once, and the analyzer does not depend on any state
+// shared with other analyzers, so that several of them can run in the same
+// driver.
+//
+// Exclusions.Symbols is used as is. To keep the built-in exclusions, add
+// DefaultExcludedSymbols to it. The analyzer is named "errcheck", like
+// Analyzer; drivers that run several of them may rename them.
+func NewAnalyzer(exclusions Exclusions) *analysis.Analyzer {
+	checker := &Checker{Exclusions: exclusions}
+	set := checker.Exclusions.prepare()
+	return &analysis.Analyzer{
+		Name: "errcheck",
+		Doc:  "check for unchecked errors",
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return checker.runPass(pass, set), nil
+		},
+		ResultType: reflect.TypeOf(Result{}),
+	}
 }
 
-var (
-	argBlank       bool
-	argAsserts     bool
-	argExcludeFile string
-	argExcludeOnly bool
-)
+// NewFlagAnalyzer returns an analyzer that is configured by flags, like
+// Analyzer, but by flags of its own, which start out unset.
+func NewFlagAnalyzer() *analysis.Analyzer {
+	args := &analyzerArgs{set: map[string]bool{}}
+	a := &analysis.Analyzer{
+		Name:       "errcheck",
+		Doc:        "check for unchecked errors",
+		Run:        args.run,
+		ResultType: reflect.TypeOf(Result{}),
+	}
+	args.register(&a.Flags)
+	return a
+}
 
-func init() {
-	Analyzer.Flags.BoolVar(&argBlank, "blank", false, "if true, check for errors assigned to blank identifier")
-	Analyzer.Flags.BoolVar(&argAsserts, "assert", false, "if true, check for ignored type assertion results")
-	Analyzer.Flags.StringVar(&argExcludeFile, "exclude", "", "Path to a file containing a list of functions to exclude from checking")
-	Analyzer.Flags.BoolVar(&argExcludeOnly, "excludeonly", false, "Use only excludes from exclude file")
+// analyzerArgs holds the values of the flags of an analyzer.
+type analyzerArgs struct {
+	blank       bool
+	asserts     bool
+	unread      bool
+	shadow      bool
+	receive     bool
+	nolint      bool
+	excludeFile string
+	excludeOnly bool
+	excludePath stringsFlag
+	config      string
+
+	ignoreTests      bool
+	ignoreGenerated  bool
+	generatedMarkers stringsFlag
+	generatedPaths   stringsFlag
+	ignorePkg        string
+	ignore           string
+
+	// set records the flags that have been set explicitly. They take
+	// precedence over the configuration file.
+	set map[string]bool
 }
 
-func runAnalyzer(pass *analysis.Pass) (interface{}, error) {
-	exclude := map[string]bool{}
-	if !argExcludeOnly {
-		for _, name := range DefaultExcludedSymbols {
-			exclude[name] = true
-		}
+func (args *analyzerArgs) register(flags *flag.FlagSet) {
+	flags.BoolVar(&args.blank, "blank", false, "if true, check for errors assigned to blank identifier")
+	flags.BoolVar(&args.asserts, "assert", false, "if true, check for ignored type assertion results")
+	flags.BoolVar(&args.unread, "unread", false, "if true, check for errors assigned to variables that are overwritten or never read")
+	flags.BoolVar(&args.shadow, "shadow", false, "if true, check for error variables that shadow an outer error that has not been checked")
+	flags.BoolVar(&args.receive, "receive", false, "if true, check for errors received from channels and discarded")
+	flags.BoolVar(&args.nolint, "nolint", false, "if true, honor //nolint:errcheck and //lint:ignore errcheck comments")
+	flags.StringVar(&args.excludeFile, "exclude", "", "Path to a file containing a list of functions to exclude from checking")
+	flags.BoolVar(&args.excludeOnly, "excludeonly", false, "Use only excludes from exclude file")
+	flags.Var(&args.excludePath, "exclude-path", "glob pattern of files to exclude from checking; may be repeated")
+	flags.BoolVar(&args.ignoreTests, "ignoretests", false, "if true, checking of _test.go files is disabled")
+	flags.BoolVar(&args.ignoreGenerated, "ignoregenerated", false, "if true, checking of files with generated code is disabled")
+	flags.Var(&args.generatedMarkers, "generated-marker", "additional text that marks files as generated in a comment before the package clause; may be repeated")
+	flags.Var(&args.generatedPaths, "generated-path", "glob pattern of generated files; may be repeated")
+	flags.StringVar(&args.ignorePkg, "ignorepkg", "", "comma-separated list of package paths to ignore")
+	flags.StringVar(&args.ignore, "ignore", "", "[deprecated] comma-separated list of pairs of the form pkg:regex\n"+
+		"            the regex is used to ignore names within pkg.")
+	flags.StringVar(&args.config, "config", "", "Path to a configuration file; flags that are set explicitly take precedence")
+
+	flags.VisitAll(func(f *flag.Flag) {
+		f.Value = trackedFlag{Value: f.Value, name: f.Name, set: args.set}
+	})
+}
+
+// stringsFlag is a flag that may be repeated to give a list of values.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set adds a value to the list. Empty values are ignored, as by the
+// command-line tool.
+func (f *stringsFlag) Set(s string) error {
+	if s != "" {
+		*f = append(*f, s)
+	}
+	return nil
+}
+
+// trackedFlag records in set that a flag has been set.
+type trackedFlag struct {
+	flag.Value
+	name string
+	set  map[string]bool
+}
+
+func (f trackedFlag) Set(s string) error {
+	if err := f.Value.Set(s); err != nil {
+		return err
+	}
+	f.set[f.name] = true
+	return nil
+}
+
+func (f trackedFlag) String() string {
+	if f.Value == nil {
+		return ""
+	}
+	return f.Value.String()
+}
+
+func (f trackedFlag) IsBoolFlag() bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// analyzerConfigs caches the configuration files read by the analyzer, which
+// runs once per package, by path. A file is read again when its modification
+// time or size changes, so that long-running drivers see edits to it.
+var analyzerConfigs sync.Map
+
+type analyzerConfig struct {
+	stamp fileStamp
+	cfg   *Config
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (s fileStamp) equal(t fileStamp) bool {
+	return s.modTime.Equal(t.modTime) && s.size == t.size
+}
+
+// stampFile returns the stamp of the file at path, or false if it cannot be
+// read.
+func stampFile(path string) (fileStamp, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{fi.ModTime(), fi.Size()}, true
+}
+
+// loadAnalyzerConfig returns the configuration file at path, or an empty
+// configuration if path is empty. Files that cannot be read are not cached.
+func loadAnalyzerConfig(path string) (*Config, error) {
+	if path == "" {
+		return &Config{}, nil
+	}
+	stamp, ok := stampFile(path)
+	if c, cached := analyzerConfigs.Load(path); ok && cached && c.(analyzerConfig).stamp.equal(stamp) {
+		return c.(analyzerConfig).cfg, nil
+	}
+	cfg, err := LoadConfig(path)
+	if ok && err == nil {
+		analyzerConfigs.Store(path, analyzerConfig{stamp, cfg})
+	}
+	return cfg, err
+}
+
+// analyzerExcludes caches the exclude files read by the analyzer by path, as
+// analyzerConfigs does the configuration files.
+var analyzerExcludes sync.Map
+
+type analyzerExclude struct {
+	stamp   fileStamp
+	symbols []string
+}
+
+// readAnalyzerExcludes returns the entries of the exclude file at path.
+func readAnalyzerExcludes(path string) ([]string, error) {
+	stamp, ok := stampFile(path)
+	if e, cached := analyzerExcludes.Load(path); ok && cached && e.(analyzerExclude).stamp.equal(stamp) {
+		return e.(analyzerExclude).symbols, nil
+	}
+	symbols, err := ReadExcludes(path)
+	if ok && err == nil {
+		analyzerExcludes.Store(path, analyzerExclude{stamp, symbols})
+	}
+	return symbols, err
+}
+
+// boolArg returns the value of a boolean flag, or that of the configuration
+// file if it sets one and the flag has not been set explicitly.
+func (args *analyzerArgs) boolArg(value bool, name string, config *bool) bool {
+	if config != nil && !args.set[name] {
+		return *config
 	}
-	if argExcludeFile != "" {
-		excludes, err := ReadExcludes(argExcludeFile)
+	return value
+}
+
+// listArg returns the values of a repeated flag, or those of the
+// configuration file if the flag has not been set explicitly.
+func (args *analyzerArgs) listArg(values []string, name string, config []string) []string {
+	if args.set[name] {
+		return values
+	}
+	return config
+}
+
+func (args *analyzerArgs) run(pass *analysis.Pass) (interface{}, error) {
+	cfg, err := loadAnalyzerConfig(args.config)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read configuration file: %v\n", err)
+	}
+
+	excludeFile := args.excludeFile
+	if excludeFile == "" && !args.set["exclude"] {
+		excludeFile = cfg.Exclude
+	}
+	var symbols []string
+	if !args.boolArg(args.excludeOnly, "excludeonly", cfg.ExcludeOnly) {
+		symbols = append(symbols, DefaultExcludedSymbols...)
+	}
+	if excludeFile != "" {
+		excludes, err := readAnalyzerExcludes(excludeFile)
 		if err != nil {
 			return nil, fmt.Errorf("Could not read exclude file: %v\n", err)
 		}
-		for _, name := range excludes {
-			exclude[name] = true
-		}
+		symbols = append(symbols, excludes...)
 	}
+	symbols = append(symbols, cfg.Symbols...)
 
-	var allErrors []UncheckedError
-	for _, f := range pass.Files {
-		v := &visitor{
-			typesInfo: pass.TypesInfo,
-			fset:      pass.Fset,
-			blank:     argBlank,
-			asserts:   argAsserts,
-			exclude:   exclude,
-			ignore:    map[string]*regexp.Regexp{}, // deprecated & not used
-			lines:     make(map[string][]string),
-			errors:    nil,
-		}
+	paths := args.listArg(args.excludePath, "exclude-path", cfg.ExcludePath)
 
-		ast.Walk(v, f)
+	pkgs := cfg.IgnorePkg
+	if args.set["ignorepkg"] {
+		pkgs = nil
+		for _, pkg := range strings.Split(args.ignorePkg, ",") {
+			if pkg != "" {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+	var regexps map[string]*regexp.Regexp
+	if args.set["ignore"] {
+		regexps, err = ParseIgnore(args.ignore)
+	} else {
+		regexps, err = cfg.IgnoreRegexps()
+	}
+	if err != nil {
+		return nil, err
+	}
+	exclusions := Exclusions{
+		Packages:               pkgs,
+		SymbolRegexpsByPackage: regexps,
+		Symbols:                symbols,
+		Paths:                  paths,
+		TestFiles:              args.boolArg(args.ignoreTests, "ignoretests", cfg.IgnoreTests),
+		GeneratedFiles:         args.boolArg(args.ignoreGenerated, "ignoregenerated", cfg.IgnoreGenerated),
+		GeneratedMarkers:       args.listArg(args.generatedMarkers, "generated-marker", cfg.GeneratedMarker),
+		GeneratedPaths:         args.listArg(args.generatedPaths, "generated-path", cfg.GeneratedPath),
+		BlankAssignments:       !args.boolArg(args.blank, "blank", cfg.Blank),
+		TypeAssertions:         !args.boolArg(args.asserts, "assert", cfg.Asserts),
+		CheckUnread:            args.boolArg(args.unread, "unread", cfg.Unread),
+		CheckShadowed:          args.boolArg(args.shadow, "shadow", cfg.Shadow),
+		CheckReceives:          args.boolArg(args.receive, "receive", cfg.Receive),
+		NolintDirectives:       args.boolArg(args.nolint, "nolint", cfg.Nolint),
+		Scopes:                 cfg.ExclusionScopes(),
+	}
+	checker := Checker{Exclusions: exclusions}
+	return checker.runPass(pass, checker.Exclusions.prepare()), nil
+}
 
-		for _, err := range v.errors {
-			pass.Report(analysis.Diagnostic{
-				Pos:      pass.Fset.File(f.Pos()).Pos(err.Pos.Offset),
-				Message:  "unchecked error",
+// runPass checks the files of pass with the prepared exclusions set and
+// reports the errors found as diagnostics.
+func (c *Checker) runPass(pass *analysis.Pass, set *exclusionSet) Result {
+	return c.checkFiles(set, pass.Pkg.Path(), pass.Fset, pass.TypesInfo, pass.Files, func(file *ast.File, errs []UncheckedError) {
+		if len(errs) == 0 {
+			return
+		}
+		tf := pass.Fset.File(file.Pos())
+		readFile := pass.ReadFile
+		if readFile == nil {
+			readFile = os.ReadFile
+		}
+		src, srcErr := readFile(tf.Name())
+		f := &fixer{pkg: pass.Pkg, info: pass.TypesInfo, file: file, tf: tf, src: src}
+		for _, err := range errs {
+			diag := analysis.Diagnostic{
+				Pos:      tf.Pos(err.Pos.Offset),
+				Message:  diagnosticMessage(err.Kind),
 				Category: "errcheck",
-			})
+			}
+			if srcErr == nil {
+				diag.SuggestedFixes = f.suggestedFixes(err)
+			}
+			if err.Kind == KindShadowed {
+				diag.Related = []analysis.RelatedInformation{{
+					Pos:     tf.Pos(err.Related.Offset),
+					Message: "shadowed declaration",
+				}}
+			}
+			pass.Report(diag)
 		}
+	})
+}
 
-		allErrors = append(allErrors, v.errors...)
+func diagnosticMessage(kind Kind) string {
+	switch kind {
+	case KindUnread:
+		return "unchecked error: assigned value is overwritten or never read"
+	case KindShadowed:
+		return "unchecked error: declaration shadows an error that has not been checked"
+	case KindReceive:
+		return "unchecked error: error received from channel is discarded"
+	case KindDirective:
+		return "errcheck:ignore directive must give a reason"
+	default:
+		return "unchecked error"
 	}
-
-	return Result{UncheckedErrors: allErrors}, nil
 }
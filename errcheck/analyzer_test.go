@@ -35,6 +35,117 @@ func TestAnalyzer(t *testing.T) {
 				_ = analysistest.Run(t, packageDir, Analyzer)
 				_ = Analyzer.Flags.Set("assert", "false") // reset it
 			})
+
+			// The other subtests use analyzers of their own, whose flags
+			// need not be reset.
+			t.Run("check unread", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/unread/")
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("unread", "true")
+				_ = analysistest.Run(t, packageDir, a)
+			})
+
+			t.Run("check shadow", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/shadow/")
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("shadow", "true")
+				_ = analysistest.Run(t, packageDir, a)
+			})
+
+			t.Run("check receive", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/receive/")
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("receive", "true")
+				_ = analysistest.Run(t, packageDir, a)
+			})
+
+			t.Run("directives", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/directives/")
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("nolint", "true")
+				_ = analysistest.Run(t, packageDir, a)
+			})
+
+			t.Run("negated excludes", func(t *testing.T) {
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("exclude", filepath.Join(analysistest.TestData(), "negated_excludes.txt"))
+				_ = analysistest.Run(t, analysistest.TestData(), a, "negated")
+			})
+
+			t.Run("exclude paths", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/excludepath/")
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("exclude-path", "**/*_mock.go")
+				_ = analysistest.Run(t, packageDir, a)
+			})
+
+			t.Run("generated files", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/generated/")
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("ignoregenerated", "true")
+				_ = a.Flags.Set("generated-marker", "Code generated by protoc-gen-gogo")
+				_ = analysistest.Run(t, packageDir, a)
+			})
+
+			t.Run("config", func(t *testing.T) {
+				a := NewFlagAnalyzer()
+				_ = a.Flags.Set("config", filepath.Join(analysistest.TestData(), "config.yaml"))
+				_ = analysistest.Run(t, analysistest.TestData(), a, "config")
+			})
+
+			t.Run("suggested fixes", func(t *testing.T) {
+				packageDir := filepath.Join(analysistest.TestData(), "src/fix/")
+				_ = analysistest.RunWithSuggestedFixes(t, packageDir, Analyzer)
+				packageDir = filepath.Join(analysistest.TestData(), "src/deferclose/")
+				_ = analysistest.RunWithSuggestedFixes(t, packageDir, Analyzer)
+			})
+
+			t.Run("new analyzer", func(t *testing.T) {
+				defaults := Exclusions{
+					Symbols:          DefaultExcludedSymbols,
+					BlankAssignments: true,
+					TypeAssertions:   true,
+				}
+				blank := defaults
+				blank.BlankAssignments = false
+
+				// The flags of Analyzer do not affect other analyzers.
+				_ = Analyzer.Flags.Set("blank", "true")
+				defer func() { _ = Analyzer.Flags.Set("blank", "false") }()
+
+				_ = analysistest.Run(t, filepath.Join(analysistest.TestData(), "src/a/"), NewAnalyzer(defaults))
+				_ = analysistest.Run(t, filepath.Join(analysistest.TestData(), "src/blank/"), NewAnalyzer(blank))
+			})
 		})
 	}
 }
+
+func TestAnalyzerFilesReloaded(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, ".errcheck.yaml")
+	excludes := filepath.Join(dir, "excludes.txt")
+
+	writeFile(t, config, "blank: [\n")
+	if _, err := loadAnalyzerConfig(config); err == nil {
+		t.Fatal("expected an error for a malformed configuration")
+	}
+	// Files that failed to load are read again.
+	writeFile(t, config, "blank: true\n")
+	if cfg, err := loadAnalyzerConfig(config); err != nil || cfg.Blank == nil || !*cfg.Blank {
+		t.Fatalf("got %+v, %v; want blank checks enabled", cfg, err)
+	}
+	// Edited files are read again.
+	writeFile(t, config, "blank: false\n")
+	if cfg, err := loadAnalyzerConfig(config); err != nil || cfg.Blank == nil || *cfg.Blank {
+		t.Fatalf("got %+v, %v; want blank checks disabled", cfg, err)
+	}
+
+	writeFile(t, excludes, "os.Remove\n")
+	if symbols, err := readAnalyzerExcludes(excludes); err != nil || len(symbols) != 1 {
+		t.Fatalf("got %q, %v; want one entry", symbols, err)
+	}
+	writeFile(t, excludes, "os.Remove\nos.Rename\n")
+	if symbols, err := readAnalyzerExcludes(excludes); err != nil || len(symbols) != 2 {
+		t.Fatalf("got %q, %v; want two entries", symbols, err)
+	}
+}
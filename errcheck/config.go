@@ -0,0 +1,280 @@
+package errcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileNames lists the names of the configuration files that FindConfig
+// looks for, in order of preference.
+var ConfigFileNames = []string{".errcheck.yaml", ".errcheck.yml", ".errcheck.json"}
+
+// Config is the contents of a configuration file. Its keys are named after
+// the command-line flags they correspond to; unset keys leave the defaults
+// in place.
+//
+// An example of a YAML configuration file is:
+//
+//	blank: true
+//	asserts: true
+//	tags: [integration]
+//	ignorepkg: [example.com/log]
+//	exclude: errcheck_excludes.txt
+//	symbols:
+//	  - (*bytes.Buffer).*
+//	ignoretests: true
+//	exclude-path: [third_party/, "**/*_mock.go"]
+type Config struct {
+	Blank           *bool `yaml:"blank" json:"blank"`
+	Asserts         *bool `yaml:"asserts" json:"asserts"`
+	Unread          *bool `yaml:"unread" json:"unread"`
+	Shadow          *bool `yaml:"shadow" json:"shadow"`
+	Receive         *bool `yaml:"receive" json:"receive"`
+	Nolint          *bool `yaml:"nolint" json:"nolint"`
+	IgnoreTests     *bool `yaml:"ignoretests" json:"ignoretests"`
+	IgnoreGenerated *bool `yaml:"ignoregenerated" json:"ignoregenerated"`
+
+	// GeneratedMarker and GeneratedPath recognize additional generated
+	// files, as in Exclusions.GeneratedMarkers and GeneratedPaths. Relative
+	// patterns of GeneratedPath are relative to the directory of the
+	// configuration file.
+	GeneratedMarker []string `yaml:"generated-marker" json:"generated-marker"`
+	GeneratedPath   []string `yaml:"generated-path" json:"generated-path"`
+
+	// Tags lists build tags.
+	Tags []string `yaml:"tags" json:"tags"`
+
+	// IgnorePkg lists paths of excluded packages.
+	IgnorePkg []string `yaml:"ignorepkg" json:"ignorepkg"`
+
+	// Ignore maps package paths to regular expressions that match symbols
+	// to be excluded, as in the deprecated -ignore flag.
+	Ignore map[string]string `yaml:"ignore" json:"ignore"`
+
+	// Exclude is the path of an excludes file. A relative path is relative
+	// to the directory of the configuration file.
+	Exclude     string `yaml:"exclude" json:"exclude"`
+	ExcludeOnly *bool  `yaml:"excludeonly" json:"excludeonly"`
+
+	// ExcludePath lists glob patterns of files that are not checked, as in
+	// Exclusions.Paths. Relative patterns are relative to the directory of
+	// the configuration file.
+	ExcludePath []string `yaml:"exclude-path" json:"exclude-path"`
+
+	// Symbols lists additional exclusion entries, in the format of an
+	// excludes file.
+	Symbols []string `yaml:"symbols" json:"symbols"`
+
+	Mod string `yaml:"mod" json:"mod"`
+
+	// Output settings of the command-line tool, which the analyzer ignores.
+	// Format is the output format, text or json.
+	Format               string `yaml:"format" json:"format"`
+	Abspath              *bool  `yaml:"abspath" json:"abspath"`
+	Verbose              *bool  `yaml:"verbose" json:"verbose"`
+	ReportUnused         *bool  `yaml:"reportunused" json:"reportunused"`
+	ReportUnusedDefaults *bool  `yaml:"reportunuseddefaults" json:"reportunuseddefaults"`
+	ReportExcluded       *bool  `yaml:"reportexcluded" json:"reportexcluded"`
+	Validate             *bool  `yaml:"validate" json:"validate"`
+
+	// Scopes lists settings that apply to some packages or files only.
+	Scopes []ConfigScope `yaml:"scopes" json:"scopes"`
+
+	// Path is the path of the file the configuration was read from.
+	Path string `yaml:"-" json:"-"`
+}
+
+// ConfigScope holds the settings of a configuration file that apply to some
+// packages or files only. See Scope.
+//
+// For example:
+//
+//	scopes:
+//	  - packages: example.com/m/cmd/tools/...
+//	    symbols: ["(*os.File).Close"]
+//	  - files: examples/**
+//	    blank: false
+type ConfigScope struct {
+	Name     string `yaml:"name" json:"name"`
+	Packages string `yaml:"packages" json:"packages"`
+
+	// Files is a glob pattern as in Scope.Files. A relative pattern is
+	// relative to the directory of the configuration file.
+	Files   string   `yaml:"files" json:"files"`
+	Symbols []string `yaml:"symbols" json:"symbols"`
+
+	Blank   *bool `yaml:"blank" json:"blank"`
+	Asserts *bool `yaml:"asserts" json:"asserts"`
+	Unread  *bool `yaml:"unread" json:"unread"`
+	Shadow  *bool `yaml:"shadow" json:"shadow"`
+	Receive *bool `yaml:"receive" json:"receive"`
+	Nolint  *bool `yaml:"nolint" json:"nolint"`
+}
+
+// LoadConfig reads a configuration file. Files whose name ends in ".json"
+// are read as JSON, all others as YAML. Unknown keys are reported as errors.
+func LoadConfig(path string) (*Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{Path: path}
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(buf))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(buf))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if _, err := cfg.IgnoreRegexps(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	symbols := cfg.Symbols
+	for i, sc := range cfg.Scopes {
+		if sc.Packages == "" && sc.Files == "" {
+			return nil, fmt.Errorf("%s: scope %d has neither packages nor files", path, i+1)
+		}
+		symbols = append(symbols[:len(symbols):len(symbols)], sc.Symbols...)
+	}
+	for _, sym := range symbols {
+		if _, err := parseSymbol(sym); err != nil {
+			return nil, fmt.Errorf("%s: invalid exclude entry %q: %v", path, sym, err)
+		}
+	}
+	if cfg.Exclude != "" && !filepath.IsAbs(cfg.Exclude) {
+		cfg.Exclude = filepath.Join(filepath.Dir(path), cfg.Exclude)
+	}
+
+	// Relative patterns are relative to the directory of the file rather
+	// than to the root of the module of each checked file.
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for _, patterns := range [][]string{cfg.ExcludePath, cfg.GeneratedPath} {
+		for i, pattern := range patterns {
+			patterns[i] = anchorGlob(pattern, dir)
+		}
+	}
+	return cfg, nil
+}
+
+// FindConfig looks for a configuration file in dir and its parent
+// directories, up to the root of the module that contains dir. It returns
+// the path of the first file found, or the empty string if there is none.
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range ConfigFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// IgnoreRegexps compiles the regular expressions of Ignore, in the form of
+// Exclusions.SymbolRegexpsByPackage.
+func (cfg *Config) IgnoreRegexps() (map[string]*regexp.Regexp, error) {
+	regexps := make(map[string]*regexp.Regexp, len(cfg.Ignore))
+	for pkg, expr := range cfg.Ignore {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern for %q: %v", pkg, err)
+		}
+		regexps[pkg] = re
+	}
+	return regexps, nil
+}
+
+// ParseIgnore parses the value of the deprecated -ignore flag, a
+// comma-separated list of pairs of the form pkg:regex, into the form of
+// Exclusions.SymbolRegexpsByPackage. The package may be omitted to have the
+// regular expression apply to all packages.
+func ParseIgnore(s string) (map[string]*regexp.Regexp, error) {
+	regexps := map[string]*regexp.Regexp{}
+	if s == "" {
+		return regexps, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		pkg, re, ok := strings.Cut(pair, ":")
+		if !ok {
+			pkg, re = "", pair
+		}
+		regex, err := regexp.Compile(re)
+		if err != nil {
+			return nil, err
+		}
+		regexps[pkg] = regex
+	}
+	return regexps, nil
+}
+
+// ExclusionScopes returns the scopes of the configuration in the form of
+// Exclusions.Scopes. Relative file globs are made relative to the directory
+// of the configuration file, and scopes are named after the globs as written.
+func (cfg *Config) ExclusionScopes() []Scope {
+	dir := filepath.Dir(cfg.Path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	var scopes []Scope
+	for _, sc := range cfg.Scopes {
+		name := sc.Name
+		if name == "" {
+			name = (&Scope{Packages: sc.Packages, Files: sc.Files}).name()
+		}
+		scopes = append(scopes, Scope{
+			Name:             name,
+			Packages:         sc.Packages,
+			Files:            anchorGlob(sc.Files, dir),
+			Symbols:          sc.Symbols,
+			BlankAssignments: negate(sc.Blank),
+			TypeAssertions:   negate(sc.Asserts),
+			CheckUnread:      sc.Unread,
+			CheckShadowed:    sc.Shadow,
+			CheckReceives:    sc.Receive,
+			NolintDirectives: sc.Nolint,
+		})
+	}
+	return scopes
+}
+
+// negate turns a setting that enables a check into one that ignores it.
+func negate(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	v := !*b
+	return &v
+}
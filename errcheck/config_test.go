@@ -0,0 +1,172 @@
+package errcheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, ".errcheck.yaml")
+	writeFile(t, yamlPath, `
+blank: true
+asserts: false
+tags: [integration, e2e]
+ignorepkg:
+  - example.com/log
+ignore:
+  fmt: "Print.*"
+exclude: excludes.txt
+symbols:
+  - (*bytes.Buffer).*
+`)
+	jsonPath := filepath.Join(dir, ".errcheck.json")
+	writeFile(t, jsonPath, `{
+	"blank": true,
+	"asserts": false,
+	"tags": ["integration", "e2e"],
+	"ignorepkg": ["example.com/log"],
+	"ignore": {"fmt": "Print.*"},
+	"exclude": "excludes.txt",
+	"symbols": ["(*bytes.Buffer).*"]
+}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Blank == nil || !*cfg.Blank || cfg.Asserts == nil || *cfg.Asserts || cfg.Unread != nil {
+			t.Errorf("%s: unexpected boolean settings %v %v %v", path, cfg.Blank, cfg.Asserts, cfg.Unread)
+		}
+		if want := []string{"integration", "e2e"}; !reflect.DeepEqual(cfg.Tags, want) {
+			t.Errorf("%s: tags got %q want %q", path, cfg.Tags, want)
+		}
+		if want := []string{"example.com/log"}; !reflect.DeepEqual(cfg.IgnorePkg, want) {
+			t.Errorf("%s: ignorepkg got %q want %q", path, cfg.IgnorePkg, want)
+		}
+		if want := map[string]string{"fmt": "Print.*"}; !reflect.DeepEqual(cfg.Ignore, want) {
+			t.Errorf("%s: ignore got %q want %q", path, cfg.Ignore, want)
+		}
+		if want := filepath.Join(dir, "excludes.txt"); cfg.Exclude != want {
+			t.Errorf("%s: exclude got %q want %q", path, cfg.Exclude, want)
+		}
+		if want := []string{"(*bytes.Buffer).*"}; !reflect.DeepEqual(cfg.Symbols, want) {
+			t.Errorf("%s: symbols got %q want %q", path, cfg.Symbols, want)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown.yaml":  "blanks: true\n",
+		"unknown.json":  `{"blanks": true}`,
+		"regexp.yaml":   "ignore:\n  fmt: \"(\"\n",
+		"symbol.yaml":   "symbols: [\"io.Copy(io.Discard\"]\n",
+		"malformed.yml": "blank: [\n",
+		"scope.yaml":    "scopes:\n  - blank: true\n",
+		"scoped.yaml":   "scopes:\n  - files: x/**\n    symbols: [\"f(\"]\n",
+	} {
+		path := filepath.Join(dir, name)
+		writeFile(t, path, content)
+		_, err := LoadConfig(path)
+		if err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: expected an error mentioning the file, got %v", name, err)
+		}
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	module := filepath.Join(root, "module")
+	nested := filepath.Join(module, "a", "b")
+	writeFile(t, filepath.Join(module, "go.mod"), "module example.com/m\n")
+	writeFile(t, filepath.Join(nested, "x.go"), "package b\n")
+
+	// A configuration above the module root is not used.
+	writeFile(t, filepath.Join(root, ".errcheck.yaml"), "blank: true\n")
+	if path, err := FindConfig(nested); err != nil || path != "" {
+		t.Errorf("got %q, %v; want no configuration", path, err)
+	}
+
+	writeFile(t, filepath.Join(module, ".errcheck.json"), "{}")
+	if path, err := FindConfig(nested); err != nil || path != filepath.Join(module, ".errcheck.json") {
+		t.Errorf("got %q, %v; want the module configuration", path, err)
+	}
+
+	writeFile(t, filepath.Join(module, "a", ".errcheck.yml"), "")
+	if path, err := FindConfig(nested); err != nil || path != filepath.Join(module, "a", ".errcheck.yml") {
+		t.Errorf("got %q, %v; want the nearest configuration", path, err)
+	}
+}
+
+func TestConfigScopes(t *testing.T) {
+	// The configuration is in a checkout below a directory named examples.
+	dir := filepath.Join(t.TempDir(), "examples", "m")
+	path := filepath.Join(dir, ".errcheck.yaml")
+	writeFile(t, path, `
+scopes:
+  - packages: example.com/m/cmd/tools/...
+    symbols: ["(*os.File).Close"]
+  - name: examples
+    files: examples/**
+    blank: false
+    nolint: true
+  - files: "**/gen/*.go"
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scopes := cfg.ExclusionScopes()
+	if len(scopes) != 3 {
+		t.Fatalf("got %d scopes, want 3", len(scopes))
+	}
+	if s := scopes[0]; s.Packages != "example.com/m/cmd/tools/..." || !reflect.DeepEqual(s.Symbols, []string{"(*os.File).Close"}) || s.BlankAssignments != nil {
+		t.Errorf("unexpected first scope %+v", s)
+	}
+	s := scopes[1]
+	if s.Name != "examples" || s.Files != filepath.ToSlash(dir)+"/examples/**" {
+		t.Errorf("unexpected second scope %+v", s)
+	}
+	// Enabling a check in the configuration means not ignoring it.
+	if s.BlankAssignments == nil || !*s.BlankAssignments {
+		t.Errorf("expected blank assignments to be ignored in the second scope")
+	}
+	if s.NolintDirectives == nil || !*s.NolintDirectives {
+		t.Errorf("expected nolint directives to be honored in the second scope")
+	}
+	// Scopes are named after their globs as written.
+	if s := scopes[2]; s.Name != "**/gen/*.go" {
+		t.Errorf("unexpected third scope %+v", s)
+	}
+
+	// The globs are relative to the directory of the configuration.
+	e := newScopedExclusions(&Exclusions{Scopes: scopes})
+	for name, want := range map[string]string{
+		"a.go":             "",
+		"examples/a.go":    "examples",
+		"x/gen/a.go":       "**/gen/*.go",
+		"../examples/a.go": "",
+	} {
+		var v visitor
+		e.configure(&v, "example.com/m", filepath.Join(dir, filepath.FromSlash(name)))
+		if v.scope != want {
+			t.Errorf("%s: got scope %q, want %q", name, v.scope, want)
+		}
+	}
+}
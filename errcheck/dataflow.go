@@ -0,0 +1,585 @@
+package errcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// assignment is a single assignment of an error result to a local variable.
+type assignment struct {
+	id   *ast.Ident
+	call *ast.CallExpr
+
+	// read is set once any path through the function reads the variable
+	// while it still holds the value of this assignment.
+	read bool
+
+	// shadowed is set for the placeholder that follows the unread value of
+	// an outer variable from the point where an inner variable shadows it.
+	// It holds the error that is reported unless the value is read later.
+	shadowed *UncheckedError
+}
+
+// flowState maps each tracked variable to the assignments whose value it may
+// hold at a given point and that have not been read on the way there. A nil
+// flowState represents an unreachable point, such as the one after a return.
+type flowState map[*types.Var]map[*assignment]bool
+
+func (s flowState) clone() flowState {
+	if s == nil {
+		return nil
+	}
+	c := make(flowState, len(s))
+	for vr, as := range s {
+		m := make(map[*assignment]bool, len(as))
+		for a := range as {
+			m[a] = true
+		}
+		c[vr] = m
+	}
+	return c
+}
+
+// merge returns the state at a point where control flow from s and other
+// joins. Either state may be modified.
+func (s flowState) merge(other flowState) flowState {
+	if s == nil {
+		return other
+	}
+	if other == nil {
+		return s
+	}
+	for vr, as := range other {
+		m, ok := s[vr]
+		if !ok {
+			s[vr] = as
+			continue
+		}
+		for a := range as {
+			m[a] = true
+		}
+	}
+	return s
+}
+
+func (s flowState) equal(other flowState) bool {
+	if (s == nil) != (other == nil) || len(s) != len(other) {
+		return false
+	}
+	for vr, as := range s {
+		bs, ok := other[vr]
+		if !ok || len(as) != len(bs) {
+			return false
+		}
+		for a := range as {
+			if !bs[a] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// flowFrame collects the states that leave a loop, switch or select statement
+// through break and continue statements.
+type flowFrame struct {
+	label     string
+	loop      bool
+	breaks    flowState
+	continues flowState
+}
+
+// maxLoopPasses bounds the number of times the body of a loop is walked
+// while looking for a fixed point. Each pass can only add assignments to the
+// state at the loop head, so this is only reached for deeply nested loops.
+const maxLoopPasses = 8
+
+// dataflow tracks error values assigned to the local variables of a single
+// function body and finds the ones that are never read.
+type dataflow struct {
+	v       *visitor
+	tracked map[*types.Var]bool
+	results []*types.Var
+	assigns map[*ast.Ident]*assignment
+	order   []*assignment
+	frames  []*flowFrame
+
+	// shadows maps the identifiers of shadowing declarations to the
+	// placeholders of the values they shadow, in shadowOrder.
+	shadows     map[*ast.Ident]*assignment
+	shadowOrder []*assignment
+}
+
+// checkDataflow reports errors assigned to local variables of the function
+// with the given type and body that are overwritten, or still unread when the
+// function returns, and error variables that shadow one of those while it
+// holds an error that is not read on any later path.
+//
+// Variables that are captured by function literals or whose address is taken
+// are not tracked, since they may be read in ways that cannot be seen here.
+// Functions containing goto statements are skipped entirely.
+func (v *visitor) checkDataflow(typ *ast.FuncType, body *ast.BlockStmt) {
+	d := &dataflow{
+		v:       v,
+		tracked: make(map[*types.Var]bool),
+		assigns: make(map[*ast.Ident]*assignment),
+		shadows: make(map[*ast.Ident]*assignment),
+	}
+	if !d.collect(typ, body) {
+		return
+	}
+	if len(d.tracked) == 0 {
+		return
+	}
+
+	if s := d.stmts(body.List, flowState{}); s != nil {
+		d.returns(s)
+	}
+	for _, p := range d.shadowOrder {
+		if !p.read {
+			d.v.addError(p.id.NamePos, *p.shadowed)
+		}
+	}
+
+	if !v.unread {
+		return
+	}
+	for _, a := range d.order {
+		if !a.read && !v.ignoreCall(a.call) {
+			v.addErrorAtPosition(a.id.NamePos, a.id.End(), a.call, KindUnread)
+		}
+	}
+}
+
+// collect finds the variables that are declared by the function and can be
+// tracked. It reports false if the function cannot be analyzed.
+func (d *dataflow) collect(typ *ast.FuncType, body *ast.BlockStmt) bool {
+	declare := func(id *ast.Ident) {
+		if id.Name == "_" {
+			return
+		}
+		if vr, ok := d.v.typesInfo.Defs[id].(*types.Var); ok && isErrorType(vr.Type()) {
+			d.tracked[vr] = true
+		}
+	}
+
+	ast.Inspect(typ, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			declare(id)
+		}
+		return true
+	})
+	if typ.Results != nil {
+		for _, field := range typ.Results.List {
+			for _, name := range field.Names {
+				if vr, ok := d.v.typesInfo.Defs[name].(*types.Var); ok {
+					d.results = append(d.results, vr)
+				}
+			}
+		}
+	}
+
+	ok := true
+	escaped := map[*types.Var]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Anything used inside a function literal escapes; its own
+			// variables are analyzed when the visitor reaches it.
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					if vr, ok := d.v.typesInfo.Uses[id].(*types.Var); ok {
+						escaped[vr] = true
+					}
+				}
+				return true
+			})
+			return false
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				if id, ok := ast.Unparen(n.X).(*ast.Ident); ok {
+					if vr, ok := d.v.typesInfo.Uses[id].(*types.Var); ok {
+						escaped[vr] = true
+					}
+				}
+			}
+		case *ast.BranchStmt:
+			if n.Tok == token.GOTO {
+				ok = false
+			}
+		case *ast.Ident:
+			declare(n)
+		}
+		return true
+	})
+	for vr := range escaped {
+		delete(d.tracked, vr)
+	}
+	return ok
+}
+
+// varOf returns the tracked variable that the identifier refers to, if any.
+func (d *dataflow) varOf(id *ast.Ident) *types.Var {
+	obj := d.v.typesInfo.Defs[id]
+	if obj == nil {
+		obj = d.v.typesInfo.Uses[id]
+	}
+	vr, ok := obj.(*types.Var)
+	if !ok || !d.tracked[vr] {
+		return nil
+	}
+	return vr
+}
+
+// read marks every assignment of vr that may reach this point as read.
+func (d *dataflow) read(vr *types.Var, s flowState) {
+	for a := range s[vr] {
+		a.read = true
+	}
+	delete(s, vr)
+}
+
+// reads marks the tracked variables used anywhere in the expression as read.
+func (d *dataflow) reads(node ast.Node, s flowState) {
+	if node == nil || s == nil {
+		return
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.Ident:
+			if vr, ok := d.v.typesInfo.Uses[n].(*types.Var); ok && d.tracked[vr] {
+				d.read(vr, s)
+			}
+		}
+		return true
+	})
+}
+
+// returns records that the function returns in state s. Named results are
+// read by the caller; anything else that is still pending is lost.
+func (d *dataflow) returns(s flowState) {
+	for _, vr := range d.results {
+		d.read(vr, s)
+	}
+}
+
+// assign records the assignment of rhs to lhs in state s.
+func (d *dataflow) assign(lhs, rhs []ast.Expr, s flowState) {
+	for _, e := range rhs {
+		d.reads(e, s)
+	}
+	for _, e := range lhs {
+		if _, ok := e.(*ast.Ident); !ok {
+			d.reads(e, s)
+		}
+	}
+
+	calls := d.v.errorCalls(lhs, rhs)
+	for i, e := range lhs {
+		id, ok := e.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if d.v.shadow {
+			d.checkShadow(id, calls[i], s)
+		}
+		vr := d.varOf(id)
+		if vr == nil {
+			continue
+		}
+		if calls[i] == nil {
+			delete(s, vr)
+			continue
+		}
+		a, ok := d.assigns[id]
+		if !ok {
+			a = &assignment{id: id, call: calls[i]}
+			d.assigns[id] = a
+			d.order = append(d.order, a)
+		}
+		s[vr] = map[*assignment]bool{a: true}
+	}
+}
+
+// checkShadow records the variable declared by id if it is an error that
+// shadows a tracked variable holding an error that has not been read in
+// state s. The variable is reported by checkDataflow unless the shadowed
+// error is read later on some path. call is the call whose result is
+// assigned to the new variable, if any.
+func (d *dataflow) checkShadow(id *ast.Ident, call *ast.CallExpr, s flowState) {
+	inner, ok := d.v.typesInfo.Defs[id].(*types.Var)
+	if !ok || !isErrorType(inner.Type()) {
+		return
+	}
+	scope := inner.Parent()
+	if scope == nil || scope.Parent() == nil {
+		return
+	}
+	_, obj := scope.Parent().LookupParent(id.Name, id.Pos())
+	outer, ok := obj.(*types.Var)
+	if !ok || !d.tracked[outer] || !d.pending(outer, s) {
+		return
+	}
+
+	p, ok := d.shadows[id]
+	if !ok {
+		e := d.v.newError(id.NamePos, id.End(), call, KindShadowed)
+		e.Related = d.v.fset.Position(outer.Pos())
+		p = &assignment{id: id, call: call, shadowed: &e}
+		d.shadows[id] = p
+		d.shadowOrder = append(d.shadowOrder, p)
+	}
+	s[outer][p] = true
+}
+
+// pending reports whether vr holds an error in state s that has not been
+// read and whose call is not excluded from checking.
+func (d *dataflow) pending(vr *types.Var, s flowState) bool {
+	for a := range s[vr] {
+		if a.shadowed == nil && !d.v.ignoreCall(a.call) {
+			return true
+		}
+	}
+	return false
+}
+
+// stmts walks a list of statements starting in state s and returns the
+// state at the end of the list.
+func (d *dataflow) stmts(list []ast.Stmt, s flowState) flowState {
+	for _, stmt := range list {
+		s = d.stmt(stmt, s, "")
+	}
+	return s
+}
+
+// stmt walks a single statement starting in state s, which it may modify,
+// and returns the state after it. label is the label of the statement, if
+// any.
+func (d *dataflow) stmt(stmt ast.Stmt, s flowState, label string) flowState {
+	if s == nil {
+		// Unreachable code.
+		return nil
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.AssignStmt:
+		if stmt.Tok != token.ASSIGN && stmt.Tok != token.DEFINE {
+			// Compound assignments like += read their operands.
+			d.reads(stmt, s)
+			return s
+		}
+		d.assign(stmt.Lhs, stmt.Rhs, s)
+
+	case *ast.DeclStmt:
+		gen, ok := stmt.Decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			break
+		}
+		for _, spec := range gen.Specs {
+			vspec := spec.(*ast.ValueSpec)
+			var lhs []ast.Expr
+			for _, name := range vspec.Names {
+				lhs = append(lhs, name)
+			}
+			d.assign(lhs, vspec.Values, s)
+		}
+
+	case *ast.ReturnStmt:
+		for _, e := range stmt.Results {
+			d.reads(e, s)
+		}
+		if len(stmt.Results) == 0 {
+			d.returns(s)
+		}
+		return nil
+
+	case *ast.BlockStmt:
+		return d.stmts(stmt.List, s)
+
+	case *ast.LabeledStmt:
+		return d.stmt(stmt.Stmt, s, stmt.Label.Name)
+
+	case *ast.IfStmt:
+		if stmt.Init != nil {
+			s = d.stmt(stmt.Init, s, "")
+		}
+		d.reads(stmt.Cond, s)
+		then := d.stmts(stmt.Body.List, s.clone())
+		if stmt.Else != nil {
+			s = d.stmt(stmt.Else, s, "")
+		}
+		return then.merge(s)
+
+	case *ast.ForStmt:
+		if stmt.Init != nil {
+			s = d.stmt(stmt.Init, s, "")
+		}
+		return d.loop(label, s, func(s flowState) flowState {
+			d.reads(stmt.Cond, s)
+			return s
+		}, stmt.Cond != nil, func(s flowState) flowState {
+			return d.stmts(stmt.Body.List, s)
+		}, stmt.Post)
+
+	case *ast.RangeStmt:
+		d.reads(stmt.X, s)
+		return d.loop(label, s, func(s flowState) flowState {
+			if stmt.Tok == token.ASSIGN || stmt.Tok == token.DEFINE {
+				var lhs []ast.Expr
+				if stmt.Key != nil {
+					lhs = append(lhs, stmt.Key)
+				}
+				if stmt.Value != nil {
+					lhs = append(lhs, stmt.Value)
+				}
+				d.assign(lhs, nil, s)
+			}
+			return s
+		}, true, func(s flowState) flowState {
+			return d.stmts(stmt.Body.List, s)
+		}, nil)
+
+	case *ast.SwitchStmt:
+		if stmt.Init != nil {
+			s = d.stmt(stmt.Init, s, "")
+		}
+		d.reads(stmt.Tag, s)
+		return d.clauses(label, s, stmt.Body, false)
+
+	case *ast.TypeSwitchStmt:
+		if stmt.Init != nil {
+			s = d.stmt(stmt.Init, s, "")
+		}
+		d.reads(stmt.Assign, s)
+		return d.clauses(label, s, stmt.Body, false)
+
+	case *ast.SelectStmt:
+		return d.clauses(label, s, stmt.Body, true)
+
+	case *ast.BranchStmt:
+		switch stmt.Tok {
+		case token.BREAK, token.CONTINUE:
+			if f := d.frame(stmt); f != nil {
+				if stmt.Tok == token.BREAK {
+					f.breaks = f.breaks.merge(s)
+				} else {
+					f.continues = f.continues.merge(s)
+				}
+			}
+			return nil
+		case token.FALLTHROUGH:
+			// Handled by clauses.
+			return s
+		}
+
+	default:
+		// Expression, send, inc/dec, go and defer statements only read.
+		d.reads(stmt, s)
+	}
+	return s
+}
+
+// frame returns the statement that a break or continue statement transfers
+// control to.
+func (d *dataflow) frame(stmt *ast.BranchStmt) *flowFrame {
+	for i := len(d.frames) - 1; i >= 0; i-- {
+		f := d.frames[i]
+		if stmt.Label != nil {
+			if f.label == stmt.Label.Name {
+				return f
+			}
+			continue
+		}
+		if f.loop || stmt.Tok == token.BREAK {
+			return f
+		}
+	}
+	return nil
+}
+
+// loop walks a for or range loop entered in state s. head is applied at the
+// start of every iteration, and exits reports whether the loop can end
+// there. post, if not nil, is walked after the body and after continue.
+func (d *dataflow) loop(label string, s flowState, head func(flowState) flowState, exits bool, body func(flowState) flowState, post ast.Stmt) flowState {
+	f := &flowFrame{label: label, loop: true}
+	d.frames = append(d.frames, f)
+	defer func() { d.frames = d.frames[:len(d.frames)-1] }()
+
+	entry := s
+	var out flowState
+	for i := 0; i < maxLoopPasses; i++ {
+		f.breaks, f.continues = nil, nil
+		h := head(entry.clone())
+		if exits {
+			out = h.clone()
+		} else {
+			out = nil
+		}
+
+		end := body(h).merge(f.continues)
+		if post != nil {
+			end = d.stmt(post, end, "")
+		}
+
+		next := entry.clone().merge(end)
+		if next.equal(entry) {
+			break
+		}
+		entry = next
+	}
+	return out.merge(f.breaks)
+}
+
+// clauses walks the case clauses of a switch, type switch or select
+// statement entered in state s.
+func (d *dataflow) clauses(label string, s flowState, body *ast.BlockStmt, isSelect bool) flowState {
+	f := &flowFrame{label: label}
+	d.frames = append(d.frames, f)
+	defer func() { d.frames = d.frames[:len(d.frames)-1] }()
+
+	var out, fall flowState
+	hasDefault := false
+	for _, clause := range body.List {
+		var list []ast.Stmt
+		start := s.clone()
+		switch clause := clause.(type) {
+		case *ast.CaseClause:
+			if clause.List == nil {
+				hasDefault = true
+			}
+			for _, e := range clause.List {
+				// Case expressions are evaluated in order until one
+				// matches, so reads in earlier cases reach later ones.
+				d.reads(e, s)
+				d.reads(e, start)
+			}
+			list = clause.Body
+		case *ast.CommClause:
+			if clause.Comm == nil {
+				hasDefault = true
+			} else {
+				start = d.stmt(clause.Comm, start, "")
+			}
+			list = clause.Body
+		}
+
+		end := d.stmts(list, start.merge(fall))
+		fall = nil
+		if n := len(list); n > 0 {
+			if br, ok := list[n-1].(*ast.BranchStmt); ok && br.Tok == token.FALLTHROUGH {
+				fall = end
+				continue
+			}
+		}
+		out = out.merge(end)
+	}
+	if !hasDefault && !isSelect {
+		// Without a default case, none of the cases may run. A select
+		// statement blocks until one of them does instead.
+		out = out.merge(s)
+	}
+	return out.merge(f.breaks)
+}
@@ -0,0 +1,210 @@
+package errcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const (
+	ignoreDirective     = "//errcheck:ignore"
+	legacyDirective     = "//errcheck:legacy"
+	nolintDirective     = "//nolint"
+	lintIgnoreDirective = "//lint:ignore"
+)
+
+// directive is an inline comment that suppresses findings.
+//
+// A directive that follows code on the same line suppresses findings on that
+// line. A directive on a line of its own suppresses findings in the statement
+// or declaration that starts on the next line of code.
+type directive struct {
+	pos    token.Pos
+	text   string
+	reason string
+
+	// used is set once the directive suppresses a finding.
+	used bool
+
+	// from and to delimit the source suppressed by the directive.
+	from, to token.Pos
+}
+
+// parseDirective reports whether the comment is a directive that suppresses
+// errcheck findings and, if so, returns the justification given for it.
+//
+// //errcheck:ignore directives are always honored, as are //errcheck:legacy
+// directives, which mark errors that were discarded when errcheck was
+// adopted and need no justification. //nolint and
+// //lint:ignore directives that apply to errcheck are honored if nolint is
+// set.
+func parseDirective(text string, nolint bool) (reason string, ok bool) {
+	switch {
+	case hasDirective(text, ignoreDirective):
+		return strings.TrimSpace(text[len(ignoreDirective):]), true
+
+	case hasDirective(text, legacyDirective):
+		if reason := strings.TrimSpace(text[len(legacyDirective):]); reason != "" {
+			return reason, true
+		}
+		return "legacy", true
+
+	case nolint && hasDirective(text, nolintDirective):
+		rest := text[len(nolintDirective):]
+		if !strings.HasPrefix(rest, ":") {
+			// A bare //nolint applies to every linter.
+			return nolintReason(rest), true
+		}
+		linters, rest, _ := strings.Cut(rest[1:], " ")
+		if !appliesToErrcheck(linters) {
+			return "", false
+		}
+		return nolintReason(rest), true
+
+	case nolint && hasDirective(text, lintIgnoreDirective):
+		fields := strings.Fields(text[len(lintIgnoreDirective):])
+		if len(fields) == 0 || !appliesToErrcheck(fields[0]) {
+			return "", false
+		}
+		return strings.Join(fields[1:], " "), true
+	}
+	return "", false
+}
+
+// hasDirective reports whether the comment text starts with the directive,
+// followed by the end of the comment, a space or an argument list.
+func hasDirective(text, name string) bool {
+	if !strings.HasPrefix(text, name) {
+		return false
+	}
+	rest := text[len(name):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == ':'
+}
+
+// nolintReason returns the explanation that may follow a //nolint directive
+// as a separate comment, as in "//nolint:errcheck // reason".
+func nolintReason(rest string) string {
+	rest = strings.TrimSpace(rest)
+	return strings.TrimSpace(strings.TrimPrefix(rest, "//"))
+}
+
+// appliesToErrcheck reports whether a comma-separated list of linter or
+// check names includes errcheck.
+func appliesToErrcheck(list string) bool {
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "errcheck" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDirectives collects the suppression directives in file. Directives
+// that are missing a required justification are reported and do not suppress
+// anything.
+func (v *visitor) parseDirectives(file *ast.File) []*directive {
+	type comment struct {
+		c      *ast.Comment
+		reason string
+	}
+	var comments []comment
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			reason, ok := parseDirective(c.Text, v.nolint)
+			if !ok {
+				continue
+			}
+			if reason == "" && hasDirective(c.Text, ignoreDirective) {
+				v.errors = append(v.errors, v.newError(c.Slash, c.End(), nil, KindDirective))
+				continue
+			}
+			comments = append(comments, comment{c, reason})
+		}
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	tf := v.fset.File(file.Pos())
+	directives := make([]*directive, len(comments))
+	for i, c := range comments {
+		line := tf.Line(c.c.Slash)
+		end := tf.Pos(tf.Size())
+		if line < tf.LineCount() {
+			end = tf.LineStart(line+1) - 1
+		}
+		directives[i] = &directive{
+			pos:    c.c.Slash,
+			text:   c.c.Text,
+			reason: c.reason,
+			from:   tf.LineStart(line),
+			to:     end,
+		}
+	}
+
+	// Find the code around each directive: whether any precedes it on the
+	// same line, and the statement or declaration that follows it.
+	trailing := make([]bool, len(comments))
+	next := make([]token.Pos, len(comments))
+	nextNode := make([]ast.Node, len(comments))
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.File, *ast.CommentGroup, *ast.Comment:
+			return true
+		}
+		for i, c := range comments {
+			for _, p := range []token.Pos{n.Pos(), n.End() - 1} {
+				if p < c.c.Slash && p >= directives[i].from {
+					trailing[i] = true
+				}
+				if p > c.c.End() && (next[i] == token.NoPos || p < next[i]) {
+					next[i] = p
+					nextNode[i] = nil
+				}
+			}
+			if n.Pos() == next[i] && nextNode[i] == nil {
+				switch n.(type) {
+				case ast.Stmt, ast.Decl:
+					nextNode[i] = n
+				}
+			}
+		}
+		return true
+	})
+	for i, d := range directives {
+		if !trailing[i] && nextNode[i] != nil {
+			d.from, d.to = nextNode[i].Pos(), nextNode[i].End()
+		}
+	}
+	return directives
+}
+
+// suppressed reports whether a finding at position is suppressed by one of
+// the directives of the file being checked.
+func (v *visitor) suppressed(position token.Pos) bool {
+	for _, d := range v.directives {
+		if d.from <= position && position <= d.to {
+			d.used = true
+			return true
+		}
+	}
+	return false
+}
+
+// recordDirectives adds the directives of the file that has been walked to
+// the usage of the visitor.
+func (v *visitor) recordDirectives() {
+	for _, d := range v.directives {
+		if hasDirective(d.text, legacyDirective) {
+			// Legacy directives mark discarded errors, which are only
+			// reported with some checks enabled, so they are never
+			// unused.
+			continue
+		}
+		v.usage.Directives = append(v.usage.Directives, Directive{
+			Pos:  v.fset.Position(d.pos),
+			Text: d.text,
+			Used: d.used,
+		})
+	}
+}
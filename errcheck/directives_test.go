@@ -0,0 +1,38 @@
+package errcheck
+
+import "testing"
+
+func TestParseDirective(t *testing.T) {
+	cases := []struct {
+		text   string
+		nolint bool
+		reason string
+		ok     bool
+	}{
+		{"//errcheck:ignore best effort", false, "best effort", true},
+		{"//errcheck:ignore   best effort  ", false, "best effort", true},
+		{"//errcheck:ignore", false, "", true},
+		{"//errcheck:ignored", false, "", false},
+		{"//errcheck:legacy", false, "legacy", true},
+		{"//errcheck:legacy // best effort", false, "// best effort", true},
+		{"// errcheck:ignore best effort", false, "", false},
+		{"//nolint:errcheck", false, "", false},
+		{"//nolint:errcheck", true, "", true},
+		{"//nolint:gosec,errcheck // best effort", true, "best effort", true},
+		{"//nolint:gosec", true, "", false},
+		{"//nolint", true, "", true},
+		{"//nolintx", true, "", false},
+		{"//lint:ignore errcheck best effort", false, "", false},
+		{"//lint:ignore errcheck best effort", true, "best effort", true},
+		{"//lint:ignore SA1019,errcheck best effort", true, "best effort", true},
+		{"//lint:ignore SA1019 best effort", true, "", false},
+		{"// just a comment", true, "", false},
+	}
+
+	for _, c := range cases {
+		reason, ok := parseDirective(c.text, c.nolint)
+		if ok != c.ok || reason != c.reason {
+			t.Errorf("parseDirective(%q, %v) = %q, %v; want %q, %v", c.text, c.nolint, reason, ok, c.reason, c.ok)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"go/types"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -29,12 +30,80 @@ var (
 	ErrNoGoFiles = errors.New("package contains no go source files")
 )
 
+// Kind identifies the check that reported an UncheckedError.
+type Kind int
+
+const (
+	// KindUnchecked is reported for a call whose error result is ignored.
+	KindUnchecked Kind = iota
+
+	// KindBlank is reported for an error result assigned to the blank identifier.
+	KindBlank
+
+	// KindAssert is reported for a type assertion whose result is not checked.
+	KindAssert
+
+	// KindUnread is reported for an error result assigned to a variable that
+	// is overwritten, or left behind when the function returns, before it is
+	// read.
+	KindUnread
+
+	// KindShadowed is reported for an error variable declared in an inner
+	// scope that shadows an outer one whose value has not been read yet and
+	// is not read later on any path.
+	KindShadowed
+
+	// KindReceive is reported for an error received from a channel and
+	// then discarded.
+	KindReceive
+
+	// KindDirective is reported for an //errcheck:ignore directive that
+	// does not give a reason. Such directives do not suppress anything.
+	KindDirective
+)
+
+var kindNames = [...]string{
+	KindUnchecked: "unchecked",
+	KindBlank:     "blank",
+	KindAssert:    "assert",
+	KindUnread:    "unread",
+	KindShadowed:  "shadowed",
+	KindReceive:   "receive",
+	KindDirective: "directive",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
 // UncheckedError indicates the position of an unchecked error return.
 type UncheckedError struct {
-	Pos          token.Position
+	Pos token.Position
+
+	// End is the position just after the expression or identifier the
+	// error was found at.
+	End token.Position
+
+	// Package is the path of the package the error was found in.
+	Package string
+
 	Line         string
 	FuncName     string
 	SelectorName string
+	Kind         Kind
+
+	// Related is the position of a declaration related to the error, such
+	// as the outer variable shadowed by a KindShadowed error. It is the zero
+	// Position if there is none.
+	Related token.Position
+
+	// Scope lists the names of the exclusion scopes that applied to the
+	// file of the error, separated by ", ", or is empty if there were
+	// none.
+	Scope string
 }
 
 // Result is returned from the CheckPackage function, and holds all the errors
@@ -46,6 +115,82 @@ type Result struct {
 	// UncheckedErrors is a list of all the unchecked errors in the package.
 	// Printing an error reports its position within the file and the contents of the line.
 	UncheckedErrors []UncheckedError
+
+	// Usage records the exclusions and inline directives that suppressed
+	// errors in the package.
+	Usage Usage
+}
+
+// Usage records which exclusions and inline directives suppressed errors
+// that would otherwise have been reported. Entries that never show up here
+// are candidates for removal.
+type Usage struct {
+	// Symbols holds the entries of Exclusions.Symbols that excluded a call.
+	Symbols map[string]bool
+
+	// Packages holds the entries of Exclusions.Packages that excluded a call.
+	Packages map[string]bool
+
+	// Regexps holds the keys of Exclusions.SymbolRegexpsByPackage whose
+	// regular expression excluded a call.
+	Regexps map[string]bool
+
+	// Directives lists the suppression directives of the checked files.
+	Directives []Directive
+
+	// Excluded lists the calls whose errors were not reported because of
+	// an exclusion, along with the exclusion responsible.
+	Excluded []ExcludedCall
+}
+
+// ExcludedCall is a call whose error would have been reported if it had not
+// been excluded.
+type ExcludedCall struct {
+	Pos      token.Position
+	FuncName string
+
+	// Symbol is the entry of Exclusions.Symbols that excluded the call, or
+	// is empty if the call was excluded by package.
+	Symbol string
+
+	// Package is the entry of Exclusions.Packages or the key of
+	// Exclusions.SymbolRegexpsByPackage that excluded the call, and Regexp
+	// the regular expression of the latter.
+	Package string
+	Regexp  string
+}
+
+// Directive is an inline comment that suppresses errors, such as
+// //errcheck:ignore.
+type Directive struct {
+	Pos  token.Position
+	Text string
+
+	// Used reports whether the directive suppressed at least one error.
+	Used bool
+}
+
+// append adds the entries of other to u.
+func (u *Usage) append(other Usage) {
+	for _, m := range []struct{ dst, src *map[string]bool }{
+		{&u.Symbols, &other.Symbols},
+		{&u.Packages, &other.Packages},
+		{&u.Regexps, &other.Regexps},
+	} {
+		for k := range *m.src {
+			markUsed(m.dst, k)
+		}
+	}
+	u.Directives = append(u.Directives, other.Directives...)
+	u.Excluded = append(u.Excluded, other.Excluded...)
+}
+
+// markUsed adds key to the set *m, allocating it if needed.
+func markUsed(m *map[string]bool, key string) {
+	if *m == nil {
+		*m = map[string]bool{}
+	}
+	(*m)[key] = true
 }
 
 type byName []UncheckedError
@@ -80,6 +225,7 @@ func (b byName) Len() int {
 // Append appends errors to e. Append does not do any duplicate checking.
 func (r *Result) Append(other Result) {
 	r.UncheckedErrors = append(r.UncheckedErrors, other.UncheckedErrors...)
+	r.Usage.append(other.Usage)
 }
 
 // Unique returns the unique errors that have been accumulated. Duplicates may occur
@@ -96,7 +242,43 @@ func (r Result) Unique() Result {
 			uniq = append(uniq, err)
 		}
 	}
-	return Result{UncheckedErrors: uniq}
+
+	// A directive is used if it suppressed an error in any of the packages
+	// its file belongs to.
+	var usage Usage
+	usage.append(Usage{Symbols: r.Usage.Symbols, Packages: r.Usage.Packages, Regexps: r.Usage.Regexps})
+	directives := make([]Directive, len(r.Usage.Directives))
+	copy(directives, r.Usage.Directives)
+	sort.SliceStable(directives, func(i, j int) bool {
+		pi, pj := directives[i].Pos, directives[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	for _, d := range directives {
+		if n := len(usage.Directives); n > 0 && usage.Directives[n-1].Pos == d.Pos {
+			usage.Directives[n-1].Used = usage.Directives[n-1].Used || d.Used
+			continue
+		}
+		usage.Directives = append(usage.Directives, d)
+	}
+
+	excluded := make([]ExcludedCall, len(r.Usage.Excluded))
+	copy(excluded, r.Usage.Excluded)
+	sort.SliceStable(excluded, func(i, j int) bool {
+		pi, pj := excluded[i].Pos, excluded[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	for i, e := range excluded {
+		if i == 0 || e != excluded[i-1] {
+			usage.Excluded = append(usage.Excluded, e)
+		}
+	}
+	return Result{UncheckedErrors: uniq, Usage: usage}
 }
 
 // Exclusions define symbols and language elements that will be not checked
@@ -122,26 +304,89 @@ type Exclusions struct {
 	//   "fmt.Errorf"              // function
 	//   "fmt.Fprintf(os.Stderr)"  // function with set argument value
 	//   "(hash.Hash).Write"       // method
+	//   "(*bytes.Buffer).*"       // every method of a type
+	//   "example.com/metrics/..." // everything in a package and below
+	//   "io.Copy(io.Discard, *)"  // function with set leading arguments
+	//   "!crypto/rand.Read"       // never excluded
+	//
+	// A "*" that does not start a pointer type matches any part of a
+	// package path element, type or function name, and "..." matches
+	// anything.
 	//
+	// Arguments are matched by their static type, the package variable or
+	// constant they refer to or their constant value; "*" matches any
+	// argument. "pkg.F(ARGS).Method" excludes Method called directly on the
+	// result of pkg.F.
+	//
+	// An entry starting with "!" is negated: calls that match it are
+	// checked even if other entries, Packages or SymbolRegexpsByPackage
+	// exclude them. This allows re-enabling individual entries of
+	// DefaultExcludedSymbols.
 	Symbols []string
 
+	// Paths lists glob patterns of files that are not checked, such as
+	// "third_party/**" or "**/*_mock.go". Patterns are matched against the
+	// slash-separated path of each file relative to the root of its module,
+	// the directory of its go.mod file, in the syntax of path.Match extended
+	// with "**" for any number of directories. A pattern that starts with
+	// "/" is matched against the absolute path instead, and a pattern that
+	// ends in "/" matches everything below a directory, so "examples/"
+	// excludes every file below the examples directory of the module.
+	Paths []string
+
 	// TestFiles excludes _test.go files.
 	TestFiles bool
 
 	// GeneratedFiles excludes generated source files.
 	//
-	// Source file is assumed to be generated if its contents
-	// match the following regular expression:
+	// A source file is assumed to be generated if ast.IsGenerated reports
+	// so, that is, if a comment line before the package clause matches
 	//
 	//   ^// Code generated .* DO NOT EDIT\\.$
 	//
+	// as specified by https://go.dev/s/generatedcode, or if it is
+	// recognized by GeneratedMarkers or GeneratedPaths.
 	GeneratedFiles bool
 
+	// GeneratedMarkers lists additional markers of generated files, for
+	// generators that do not follow the convention above. A file is
+	// generated if a comment line before its package clause contains one
+	// of them, as in "Code generated by protoc-gen-gogo" or "This file was
+	// generated by the swagger tool".
+	GeneratedMarkers []string
+
+	// GeneratedPaths lists glob patterns of the names of generated files,
+	// such as "**/*.pb.go" or "**/zz_generated.*.go", in the syntax of Paths.
+	GeneratedPaths []string
+
 	// BlankAssignments ignores assignments to blank identifier.
 	BlankAssignments bool
 
 	// TypeAssertions ignores unchecked type assertions.
 	TypeAssertions bool
+
+	// CheckUnread reports errors that are assigned to a variable and then
+	// overwritten, or left behind when the function returns, before they
+	// are read.
+	CheckUnread bool
+
+	// CheckShadowed reports error variables that shadow an outer error
+	// variable whose value has not been read yet and is not read later.
+	CheckShadowed bool
+
+	// CheckReceives reports errors that are received from a channel and
+	// discarded, either by a receive statement, a select case that does not
+	// assign the received value or an assignment to the blank identifier.
+	CheckReceives bool
+
+	// NolintDirectives excludes findings suppressed by //nolint:errcheck and
+	// //lint:ignore errcheck comments. Findings suppressed by
+	// //errcheck:ignore comments that give a reason are always excluded.
+	NolintDirectives bool
+
+	// Scopes lists sets of exclusion rules that apply to some packages or
+	// files only, overriding the settings above.
+	Scopes []Scope
 }
 
 // Checker checks that you checked errors.
@@ -164,34 +409,83 @@ var loadPackages = func(cfg *packages.Config, paths ...string) ([]*packages.Pack
 // LoadPackages loads all the packages in all the paths provided. It uses the
 // exclusions and build tags provided to by the user when loading the packages.
 func (c *Checker) LoadPackages(paths ...string) ([]*packages.Package, error) {
-	buildFlags := []string{fmt.Sprintf("-tags=%s", strings.Join(c.Tags, ","))}
-	if c.Mod != "" {
-		buildFlags = append(buildFlags, fmt.Sprintf("-mod=%s", c.Mod))
-	}
 	cfg := &packages.Config{
-		Mode:       packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Mode:       packages.NeedName | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
 		Tests:      !c.Exclusions.TestFiles,
-		BuildFlags: buildFlags,
+		BuildFlags: c.buildFlags(),
 	}
 	return loadPackages(cfg, paths...)
 }
 
-var generatedCodeRegexp = regexp.MustCompile("^// Code generated .* DO NOT EDIT\\.$")
+// buildFlags returns the flags for the go command that apply the build tags
+// and module mode of c.
+func (c *Checker) buildFlags() []string {
+	buildFlags := []string{fmt.Sprintf("-tags=%s", strings.Join(c.Tags, ","))}
+	if c.Mod != "" {
+		buildFlags = append(buildFlags, fmt.Sprintf("-mod=%s", c.Mod))
+	}
+	return buildFlags
+}
+
 var dotStar = regexp.MustCompile(".*")
 
-func (c *Checker) shouldSkipFile(file *ast.File) bool {
-	if !c.Exclusions.GeneratedFiles {
-		return false
+// shouldSkipFile reports whether a file is excluded from checking as a
+// generated file, a test file or by Paths.
+func (c *Checker) shouldSkipFile(filename string, file *ast.File) bool {
+	e := &c.Exclusions
+	switch {
+	case e.GeneratedFiles && e.isGenerated(filename, file):
+		return true
+	case e.TestFiles && strings.HasSuffix(filename, "_test.go"):
+		return true
 	}
+	return e.excludedPath(filename)
+}
 
+// isGenerated reports whether a file is generated, as described for
+// GeneratedFiles.
+func (e *Exclusions) isGenerated(filename string, file *ast.File) bool {
+	if ast.IsGenerated(file) || matchPaths(e.GeneratedPaths, filename) {
+		return true
+	}
+	if len(e.GeneratedMarkers) == 0 {
+		return false
+	}
 	for _, cg := range file.Comments {
-		for _, comment := range cg.List {
-			if generatedCodeRegexp.MatchString(comment.Text) {
-				return true
+		if cg.Pos() > file.Package {
+			break
+		}
+		for _, line := range strings.Split(cg.Text(), "\n") {
+			for _, marker := range e.GeneratedMarkers {
+				if strings.Contains(line, marker) {
+					return true
+				}
 			}
 		}
 	}
+	return false
+}
+
+// excludedPath reports whether the file matches one of the patterns of Paths.
+func (e *Exclusions) excludedPath(filename string) bool {
+	return matchPaths(e.Paths, filename)
+}
 
+// matchPaths reports whether the file matches one of the patterns, in the
+// syntax of Exclusions.Paths.
+func matchPaths(patterns []string, filename string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	base := patternBase(filename)
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		if matchFileGlob(pattern, filename, base) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -200,43 +494,86 @@ func (c *Checker) shouldSkipFile(file *ast.File) bool {
 // It will exclude specific errors from analysis if the user has configured
 // exclusions.
 func (c *Checker) CheckPackage(pkg *packages.Package) Result {
-	excludedSymbols := map[string]bool{}
-	for _, sym := range c.Exclusions.Symbols {
-		excludedSymbols[sym] = true
+	return c.checkFiles(c.Exclusions.prepare(), pkg.Types.Path(), pkg.Fset, pkg.TypesInfo, pkg.Syntax, nil)
+}
+
+// checkFiles checks the files of the package with the given path. It is
+// shared by CheckPackage and the Analyzer, so that both apply the same
+// exclusions. If report is not nil, it is called with the errors found in
+// each file that is checked; their positions may be adjusted by //line
+// directives and so do not always name the file.
+func (c *Checker) checkFiles(set *exclusionSet, pkgPath string, fset *token.FileSet, info *types.Info, files []*ast.File, report func(*ast.File, []UncheckedError)) Result {
+	v := &visitor{
+		typesInfo:     info,
+		fset:          fset,
+		pkgPath:       pkgPath,
+		ignore:        set.ignore,
+		ignoreEntries: set.ignoreEntries,
+		lines:         make(map[string][]string),
+		errors:        []UncheckedError{},
 	}
 
+	for _, astFile := range files {
+		filename := fset.File(astFile.Pos()).Name()
+		if c.shouldSkipFile(filename, astFile) {
+			continue
+		}
+		set.scoped.configure(v, pkgPath, filename)
+		n := len(v.errors)
+		v.directives = v.parseDirectives(astFile)
+		ast.Walk(v, astFile)
+		v.recordDirectives()
+		if report != nil {
+			report(astFile, v.errors[n:])
+		}
+	}
+	return Result{UncheckedErrors: v.errors, Usage: v.usage}
+}
+
+// exclusionSet holds Exclusions prepared for checking packages. It is safe
+// for concurrent use.
+type exclusionSet struct {
+	ignore        map[string]*regexp.Regexp
+	ignoreEntries map[string]ignoreEntry
+	scoped        *scopedExclusions
+}
+
+// prepare compiles the exclusions for checking packages.
+func (e *Exclusions) prepare() *exclusionSet {
+	set := &exclusionSet{scoped: newScopedExclusions(e)}
+	set.ignore, set.ignoreEntries = e.ignored()
+	return set
+}
+
+// ignored returns the regular expressions that match the names of excluded
+// symbols, keyed by package path, along with the exclusions they were built
+// from.
+func (e *Exclusions) ignored() (map[string]*regexp.Regexp, map[string]ignoreEntry) {
 	ignore := map[string]*regexp.Regexp{}
+	ignoreEntries := map[string]ignoreEntry{}
 	// Apply SymbolRegexpsByPackage first so that if the same path appears in
 	// Packages, a more narrow regexp will be superseded by dotStar below.
-	if regexps := c.Exclusions.SymbolRegexpsByPackage; regexps != nil {
+	if regexps := e.SymbolRegexpsByPackage; regexps != nil {
 		for pkg, re := range regexps {
 			// TODO warn if previous entry overwritten?
 			ignore[nonVendoredPkgPath(pkg)] = re
+			ignoreEntries[nonVendoredPkgPath(pkg)] = ignoreEntry{name: pkg}
 		}
 	}
-	for _, pkg := range c.Exclusions.Packages {
+	for _, pkg := range e.Packages {
 		// TODO warn if previous entry overwritten?
 		ignore[nonVendoredPkgPath(pkg)] = dotStar
+		ignoreEntries[nonVendoredPkgPath(pkg)] = ignoreEntry{name: pkg, pkg: true}
 	}
+	return ignore, ignoreEntries
+}
 
-	v := &visitor{
-		typesInfo: pkg.TypesInfo,
-		fset:      pkg.Fset,
-		ignore:    ignore,
-		blank:     !c.Exclusions.BlankAssignments,
-		asserts:   !c.Exclusions.TypeAssertions,
-		lines:     make(map[string][]string),
-		exclude:   excludedSymbols,
-		errors:    []UncheckedError{},
-	}
-
-	for _, astFile := range pkg.Syntax {
-		if c.shouldSkipFile(astFile) {
-			continue
-		}
-		ast.Walk(v, astFile)
-	}
-	return Result{UncheckedErrors: v.errors}
+// ignoreEntry identifies the exclusion that an entry of visitor.ignore was
+// built from: a package path from Exclusions.Packages or a key of
+// Exclusions.SymbolRegexpsByPackage.
+type ignoreEntry struct {
+	name string
+	pkg  bool
 }
 
 // visitor implements the errcheck algorithm
@@ -246,8 +583,28 @@ type visitor struct {
 	ignore    map[string]*regexp.Regexp
 	blank     bool
 	asserts   bool
+	unread    bool
+	shadow    bool
+	receives  bool
+	nolint    bool
 	lines     map[string][]string
-	exclude   map[string]bool
+	exclude   *symbolMatcher
+
+	// pkgPath is the path of the package being checked.
+	pkgPath string
+
+	// ignoreEntries maps the keys of ignore to the exclusions they were
+	// built from, so that their use can be recorded.
+	ignoreEntries map[string]ignoreEntry
+
+	// scope names the exclusion scopes that apply to the file being walked.
+	scope string
+
+	// directives are the suppression directives of the file being walked.
+	directives []*directive
+
+	// usage records the exclusions and directives that suppressed errors.
+	usage Usage
 
 	errors []UncheckedError
 }
@@ -294,10 +651,9 @@ func (v *visitor) fullName(call *ast.CallExpr) string {
 		return ""
 	}
 
-	// TODO(dh): vendored packages will have /vendor/ in their name,
-	// thus not matching vendored standard library packages. If we
-	// want to support vendored stdlib packages, we need to implement
-	// FullName with our own logic.
+	// Vendored packages will have /vendor/ in their name. The name is
+	// reported as is; namesForExcludeCheck adds the unvendored name for
+	// matching exclusions.
 	return fn.FullName()
 }
 
@@ -342,6 +698,12 @@ func (v *visitor) selectorName(call *ast.CallExpr) string {
 // Otherwise, we walk through all the potentially embedded interfaces of the receiver
 // to collect a list of type-qualified function names that we will check.
 func (v *visitor) namesForExcludeCheck(call *ast.CallExpr) []string {
+	return withNonVendoredNames(v.calledNames(call))
+}
+
+// calledNames returns the names of the function called by call, as described
+// for namesForExcludeCheck, without removing vendor directories.
+func (v *visitor) calledNames(call *ast.CallExpr) []string {
 	sel, fn, ok := v.selectorAndFunc(call)
 	if !ok {
 		return nil
@@ -368,54 +730,150 @@ func (v *visitor) namesForExcludeCheck(call *ast.CallExpr) []string {
 
 	result := make([]string, len(ts))
 	for i, t := range ts {
-		// Like in fullName, vendored packages will have /vendor/ in their name,
-		// thus not matching vendored standard library packages. If we
-		// want to support vendored stdlib packages, we need to implement
-		// additional logic here.
 		result[i] = fmt.Sprintf("(%s).%s", t.String(), fn.Name())
 	}
 	return result
 }
 
-// isBufferType checks if the expression type is a known in-memory buffer type.
-func (v *visitor) argName(expr ast.Expr) string {
-	// Special-case literal "os.Stdout" and "os.Stderr"
-	if sel, ok := expr.(*ast.SelectorExpr); ok {
-		if obj := v.typesInfo.ObjectOf(sel.Sel); obj != nil {
-			vr, ok := obj.(*types.Var)
-			if ok && vr.Pkg() != nil && vr.Pkg().Name() == "os" && (vr.Name() == "Stderr" || vr.Name() == "Stdout") {
-				return "os." + vr.Name()
+// argNames returns the names that an argument expression can be matched
+// by in an exclusion entry: the qualified name of the package variable or
+// constant it refers to, its exact constant value and its static type.
+func (v *visitor) argNames(expr ast.Expr) []string {
+	var names []string
+	var id *ast.Ident
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	}
+	if id != nil {
+		switch obj := v.typesInfo.ObjectOf(id).(type) {
+		case *types.Var, *types.Const:
+			if pkg := obj.Pkg(); pkg != nil && obj.Parent() == pkg.Scope() {
+				names = append(names, nonVendoredPkgPath(pkg.Path())+"."+obj.Name())
 			}
 		}
 	}
-	t := v.typesInfo.TypeOf(expr)
-	if t == nil {
-		return ""
+	tv, ok := v.typesInfo.Types[expr]
+	if !ok {
+		return names
+	}
+	if tv.Value != nil {
+		names = append(names, tv.Value.ExactString())
+	}
+	if tv.IsNil() {
+		names = append(names, "nil")
 	}
-	return t.String()
+	if tv.Type != nil {
+		names = append(names, tv.Type.String())
+	}
+	return withNonVendoredNames(names)
+}
+
+// argsMatch reports whether the leading arguments of a call match the
+// argument patterns of an exclusion entry.
+func (v *visitor) argsMatch(patterns []string, args []ast.Expr) bool {
+	if len(patterns) > len(args) {
+		return false
+	}
+	for i, pattern := range patterns {
+		if pattern == "*" || pattern == "_" {
+			continue
+		}
+		names := v.argNames(args[i])
+		if re := anyMajorRegexp(pattern); re != nil {
+			if !slices.ContainsFunc(names, re.MatchString) {
+				return false
+			}
+		} else if !slices.Contains(names, pattern) {
+			return false
+		}
+	}
+	return true
 }
 
-func (v *visitor) excludeCall(call *ast.CallExpr) bool {
-	var arg0 string
-	if len(call.Args) > 0 {
-		arg0 = v.argName(call.Args[0])
+// excludeCall returns the entries of Exclusions.Symbols that match a call:
+// the first entry that excludes it and the first negated entry, which
+// prevents it from being excluded.
+func (v *visitor) excludeCall(call *ast.CallExpr) (excluded, enforced *symbolRule) {
+	found := func(r *symbolRule) {
+		switch {
+		case r.negated && enforced == nil:
+			enforced = r
+		case !r.negated && excluded == nil:
+			excluded = r
+		}
 	}
+
 	for _, name := range v.namesForExcludeCheck(call) {
-		if v.exclude[name] {
-			return true
+		for _, r := range v.exclude.match(name) {
+			if r.method == "" && v.argsMatch(r.args, call.Args) {
+				found(r)
+			}
 		}
-		if arg0 != "" && v.exclude[name+"("+arg0+")"] {
-			return true
+	}
+
+	// Look for entries that exclude the method called on the result of
+	// another call, as in json.NewEncoder(w).Encode(v).
+	sel, ok := baseCallExpr(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return excluded, enforced
+	}
+	inner, ok := ast.Unparen(sel.X).(*ast.CallExpr)
+	if !ok {
+		return excluded, enforced
+	}
+	for _, name := range v.namesForExcludeCheck(inner) {
+		for _, r := range v.exclude.match(name) {
+			if r.method == sel.Sel.Name && v.argsMatch(r.args, inner.Args) && v.argsMatch(r.methodArgs, call.Args) {
+				found(r)
+			}
 		}
 	}
-	return false
+	return excluded, enforced
 }
 
+// ignoreCall reports whether a call is excluded from checking, either by an
+// entry of Exclusions.Symbols or by a package regexp. Negated entries take
+// precedence over both.
 func (v *visitor) ignoreCall(call *ast.CallExpr) bool {
-	if v.excludeCall(call) {
+	excluded, enforced := v.excludeCall(call)
+	path, ignored := v.ignoredByRegexp(call)
+	switch {
+	case enforced != nil:
+		if excluded != nil || ignored {
+			markUsed(&v.usage.Symbols, enforced.entry)
+		}
+		return false
+	case excluded != nil:
+		markUsed(&v.usage.Symbols, excluded.entry)
+		v.recordExcluded(call, ExcludedCall{Symbol: excluded.entry})
+		return true
+	case ignored:
+		v.markIgnoreUsed(path)
+		entry := v.ignoreEntries[path]
+		e := ExcludedCall{Package: entry.name}
+		if !entry.pkg {
+			e.Regexp = v.ignore[path].String()
+		}
+		v.recordExcluded(call, e)
 		return true
 	}
+	return false
+}
+
+// recordExcluded adds the excluded call to the usage of v.
+func (v *visitor) recordExcluded(call *ast.CallExpr, e ExcludedCall) {
+	e.Pos = v.fset.Position(call.Pos())
+	e.FuncName = v.fullName(call)
+	v.usage.Excluded = append(v.usage.Excluded, e)
+}
 
+// ignoredByRegexp reports whether the name of the called function matches
+// the regexp of ignore for its package, and returns the package path the
+// regexp was found under.
+func (v *visitor) ignoredByRegexp(call *ast.CallExpr) (string, bool) {
 	// Try to get an identifier.
 	// Currently only supports simple expressions:
 	//     1. f()
@@ -432,23 +890,37 @@ func (v *visitor) ignoreCall(call *ast.CallExpr) bool {
 	}
 
 	if id == nil {
-		return false
+		return "", false
 	}
 
 	// If we got an identifier for the function, see if it is ignored
 	if re, ok := v.ignore[""]; ok && re.MatchString(id.Name) {
-		return true
+		return "", true
 	}
 
 	if obj := v.typesInfo.Uses[id]; obj != nil {
 		if pkg := obj.Pkg(); pkg != nil {
-			if re, ok := v.ignore[nonVendoredPkgPath(pkg.Path())]; ok {
-				return re.MatchString(id.Name)
+			path := nonVendoredPkgPath(pkg.Path())
+			if re, ok := v.ignore[path]; ok {
+				return path, re.MatchString(id.Name)
 			}
 		}
 	}
 
-	return false
+	return "", false
+}
+
+// markIgnoreUsed records that the entry of ignore for the package path
+// excluded a call.
+func (v *visitor) markIgnoreUsed(path string) {
+	e, ok := v.ignoreEntries[path]
+	switch {
+	case !ok:
+	case e.pkg:
+		markUsed(&v.usage.Packages, e.name)
+	default:
+		markUsed(&v.usage.Regexps, e.name)
+	}
 }
 
 // baseCallExpr returns the underlying function expression for a call. Type
@@ -480,35 +952,91 @@ func nonVendoredPkgPath(pkgPath string) string {
 	return pkgPath[lastVendorIndex+len("/vendor/"):]
 }
 
+// vendorPrefix matches the part of a package path up to and including its
+// last vendor directory.
+var vendorPrefix = regexp.MustCompile(`[\w.~/-]*/vendor/`)
+
+// withNonVendoredNames adds to names the names of functions, methods and
+// types with the vendor directories removed from the package paths in them,
+// in the same way as nonVendoredPkgPath, so that exclusions do not have to
+// name the vendor directory.
+func withNonVendoredNames(names []string) []string {
+	for _, name := range names {
+		if !strings.Contains(name, "/vendor/") {
+			continue
+		}
+		if n := vendorPrefix.ReplaceAllString(name, ""); !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
 // errorsByArg returns a slice s such that
 // len(s) == number of return types of call
 // s[i] == true iff return type at position i from left is an error type
 func (v *visitor) errorsByArg(call *ast.CallExpr) []bool {
 	switch t := v.typesInfo.Types[call].Type.(type) {
-	case *types.Named:
-		// Single return
-		return []bool{isErrorType(t)}
-	case *types.Pointer:
-		// Single return via pointer
-		return []bool{isErrorType(t)}
+	case nil:
+		return []bool{false}
 	case *types.Tuple:
 		// Multiple returns
 		s := make([]bool, t.Len())
 		for i := 0; i < t.Len(); i++ {
-			switch et := t.At(i).Type().(type) {
-			case *types.Named:
-				// Single return
-				s[i] = isErrorType(et)
-			case *types.Pointer:
-				// Single return via pointer
-				s[i] = isErrorType(et)
-			default:
-				s[i] = false
-			}
+			s[i] = isErrorResult(t.At(i).Type())
 		}
 		return s
+	default:
+		// Single return
+		return []bool{isErrorResult(t)}
 	}
-	return []bool{false}
+}
+
+// isErrorResult reports whether a value of type t returned from a call is an
+// error. Besides named types and pointers to them, this covers aliases such as
+// "type E = error", type parameters constrained by error and unnamed
+// interfaces that embed error.
+func isErrorResult(t types.Type) bool {
+	switch t := maybeUnalias(t).(type) {
+	case *types.Named, *types.Pointer, *types.Interface:
+		return isErrorType(t)
+	case *types.TypeParam:
+		return isErrorType(t) || termsAreErrors(t)
+	default:
+		return false
+	}
+}
+
+// termsAreErrors reports whether the constraint of the type parameter
+// restricts it to a set of types that all implement error, as in
+// [T interface{ *E1 | *E2 }].
+func termsAreErrors(tp *types.TypeParam) bool {
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	found := false
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		var terms []types.Type
+		switch e := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < e.Len(); j++ {
+				terms = append(terms, e.Term(j).Type())
+			}
+		default:
+			if types.IsInterface(e) {
+				continue
+			}
+			terms = append(terms, e)
+		}
+		for _, term := range terms {
+			if !isErrorType(term) {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
 }
 
 func (v *visitor) callReturnsError(call *ast.CallExpr) bool {
@@ -536,7 +1064,22 @@ func (v *visitor) isRecover(call *ast.CallExpr) bool {
 // TODO (dtcaciuc) collect token.Pos and then convert them to UncheckedErrors
 // after visitor is done running. This will allow to integrate more cleanly
 // with analyzer so that we don't have to convert Position back to Pos.
-func (v *visitor) addErrorAtPosition(position token.Pos, call *ast.CallExpr) {
+func (v *visitor) addErrorAtPosition(position, end token.Pos, call *ast.CallExpr, kind Kind) {
+	v.addError(position, v.newError(position, end, call, kind))
+}
+
+// addError records e, found at position, unless it is suppressed by an
+// inline directive.
+func (v *visitor) addError(position token.Pos, e UncheckedError) {
+	if v.suppressed(position) {
+		return
+	}
+	v.errors = append(v.errors, e)
+}
+
+// newError returns an UncheckedError of the given kind that spans from
+// position to end. call is the call that produced the error, if any.
+func (v *visitor) newError(position, end token.Pos, call *ast.CallExpr, kind Kind) UncheckedError {
 	pos := v.fset.Position(position)
 	lines, ok := v.lines[pos.Filename]
 	if !ok {
@@ -556,7 +1099,16 @@ func (v *visitor) addErrorAtPosition(position token.Pos, call *ast.CallExpr) {
 		sel = v.selectorName(call)
 	}
 
-	v.errors = append(v.errors, UncheckedError{pos, line, name, sel})
+	return UncheckedError{
+		Pos:          pos,
+		End:          v.fset.Position(end),
+		Package:      v.pkgPath,
+		Line:         line,
+		FuncName:     name,
+		SelectorName: sel,
+		Kind:         kind,
+		Scope:        v.scope,
+	}
 }
 
 func readfile(filename string) []string {
@@ -578,17 +1130,20 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 	switch stmt := node.(type) {
 	case *ast.ExprStmt:
 		if call, ok := stmt.X.(*ast.CallExpr); ok {
-			if !v.ignoreCall(call) && v.callReturnsError(call) {
-				v.addErrorAtPosition(call.Lparen, call)
+			if v.callReturnsError(call) && !v.ignoreCall(call) {
+				v.addErrorAtPosition(call.Lparen, call.End(), call, KindUnchecked)
 			}
+		} else if recv, ok := v.errorReceive(stmt.X); ok && v.receives {
+			// This also covers select cases that do not assign the received value.
+			v.addErrorAtPosition(recv.OpPos, recv.End(), nil, KindReceive)
 		}
 	case *ast.GoStmt:
-		if !v.ignoreCall(stmt.Call) && v.callReturnsError(stmt.Call) {
-			v.addErrorAtPosition(stmt.Call.Lparen, stmt.Call)
+		if v.callReturnsError(stmt.Call) && !v.ignoreCall(stmt.Call) {
+			v.addErrorAtPosition(stmt.Call.Lparen, stmt.Call.End(), stmt.Call, KindUnchecked)
 		}
 	case *ast.DeferStmt:
-		if !v.ignoreCall(stmt.Call) && v.callReturnsError(stmt.Call) {
-			v.addErrorAtPosition(stmt.Call.Lparen, stmt.Call)
+		if v.callReturnsError(stmt.Call) && !v.ignoreCall(stmt.Call) {
+			v.addErrorAtPosition(stmt.Call.Lparen, stmt.Call.End(), stmt.Call, KindUnchecked)
 		}
 	case *ast.GenDecl:
 		if stmt.Tok != token.VAR {
@@ -623,6 +1178,19 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		v.checkAssertExpr(stmt)
 		return nil
 
+	case *ast.RangeStmt:
+		v.checkRange(stmt)
+
+	case *ast.FuncDecl:
+		if (v.unread || v.shadow) && stmt.Body != nil {
+			v.checkDataflow(stmt.Type, stmt.Body)
+		}
+
+	case *ast.FuncLit:
+		if v.unread || v.shadow {
+			v.checkDataflow(stmt.Type, stmt.Body)
+		}
+
 	default:
 	}
 	return v
@@ -637,19 +1205,25 @@ func (v *visitor) checkAssignment(lhs, rhs []ast.Expr) (followed bool) {
 			if !v.blank {
 				return true
 			}
-			if v.ignoreCall(call) {
-				return true
-			}
 			isError := v.errorsByArg(call)
 			for i := 0; i < len(lhs); i++ {
 				if id, ok := lhs[i].(*ast.Ident); ok {
 					// We shortcut calls to recover() because errorsByArg can't
 					// check its return types for errors since it returns interface{}.
 					if id.Name == "_" && (v.isRecover(call) || isError[i]) {
-						v.addErrorAtPosition(id.NamePos, call)
+						if v.ignoreCall(call) {
+							return true
+						}
+						v.addErrorAtPosition(id.NamePos, id.End(), call, KindBlank)
 					}
 				}
 			}
+		} else if _, ok := v.errorReceive(rhs[0]); ok {
+			if v.receives && len(lhs) > 0 {
+				if id, ok := lhs[0].(*ast.Ident); ok && id.Name == "_" {
+					v.addErrorAtPosition(id.NamePos, id.End(), nil, KindReceive)
+				}
+			}
 		} else if assert, ok := rhs[0].(*ast.TypeAssertExpr); ok {
 			if !v.asserts {
 				return false
@@ -660,10 +1234,10 @@ func (v *visitor) checkAssignment(lhs, rhs []ast.Expr) (followed bool) {
 			}
 			if len(lhs) < 2 {
 				// assertion result not read
-				v.addErrorAtPosition(rhs[0].Pos(), nil)
+				v.addErrorAtPosition(rhs[0].Pos(), rhs[0].End(), nil, KindAssert)
 			} else if id, ok := lhs[1].(*ast.Ident); ok && v.blank && id.Name == "_" {
 				// assertion result ignored
-				v.addErrorAtPosition(id.NamePos, nil)
+				v.addErrorAtPosition(id.NamePos, id.End(), nil, KindAssert)
 			}
 			return false
 		}
@@ -676,11 +1250,12 @@ func (v *visitor) checkAssignment(lhs, rhs []ast.Expr) (followed bool) {
 					if !v.blank {
 						continue
 					}
-					if v.ignoreCall(call) {
-						continue
+					if id.Name == "_" && v.callReturnsError(call) && !v.ignoreCall(call) {
+						v.addErrorAtPosition(id.NamePos, id.End(), call, KindBlank)
 					}
-					if id.Name == "_" && v.callReturnsError(call) {
-						v.addErrorAtPosition(id.NamePos, call)
+				} else if _, ok := v.errorReceive(rhs[i]); ok {
+					if v.receives && id.Name == "_" {
+						v.addErrorAtPosition(id.NamePos, id.End(), nil, KindReceive)
 					}
 				} else if assert, ok := rhs[i].(*ast.TypeAssertExpr); ok {
 					if !v.asserts {
@@ -690,7 +1265,7 @@ func (v *visitor) checkAssignment(lhs, rhs []ast.Expr) (followed bool) {
 						// Shouldn't happen anyway, no multi assignment in type switches
 						continue
 					}
-					v.addErrorAtPosition(id.NamePos, nil)
+					v.addErrorAtPosition(id.NamePos, id.End(), nil, KindAssert)
 				}
 			}
 		}
@@ -699,6 +1274,100 @@ func (v *visitor) checkAssignment(lhs, rhs []ast.Expr) (followed bool) {
 	return true
 }
 
+// errorCalls returns a slice s such that len(s) == len(lhs) and s[i] is the
+// call whose error result is assigned to lhs[i], or nil if lhs[i] is assigned
+// something else. Calls that are excluded from checking are included as well;
+// callers consult ignoreCall only before reporting an error, so that the use
+// of exclusions is recorded accurately.
+func (v *visitor) errorCalls(lhs, rhs []ast.Expr) []*ast.CallExpr {
+	calls := make([]*ast.CallExpr, len(lhs))
+	if len(rhs) == 1 && len(lhs) > 1 {
+		// a single call on rhs returning multiple values
+		call, ok := rhs[0].(*ast.CallExpr)
+		if !ok {
+			return calls
+		}
+		isError := v.errorsByArg(call)
+		for i := range lhs {
+			if i < len(isError) && isError[i] {
+				calls[i] = call
+			}
+		}
+		return calls
+	}
+	for i := range lhs {
+		if i >= len(rhs) {
+			break
+		}
+		call, ok := rhs[i].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		if isError := v.errorsByArg(call); len(isError) == 1 && isError[0] {
+			calls[i] = call
+		}
+	}
+	return calls
+}
+
+// errorReceive returns the receive operation that expr consists of if the
+// channel it receives from carries errors.
+func (v *visitor) errorReceive(expr ast.Expr) (*ast.UnaryExpr, bool) {
+	recv, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+	if !ok || recv.Op != token.ARROW {
+		return nil, false
+	}
+	t := v.typesInfo.TypeOf(recv.X)
+	if t == nil {
+		return nil, false
+	}
+	ch, ok := t.Underlying().(*types.Chan)
+	if !ok || !isErrorResult(ch.Elem()) {
+		return nil, false
+	}
+	return recv, true
+}
+
+// checkRange checks a range statement over an iterator function whose second
+// yielded value is an error, such as an iter.Seq2[T, error]. Ranging without
+// a second iteration variable drops every error; assigning it to the blank
+// identifier is reported like any other blank assignment.
+func (v *visitor) checkRange(stmt *ast.RangeStmt) {
+	if !v.yieldsError(stmt.X) {
+		return
+	}
+	call, _ := ast.Unparen(stmt.X).(*ast.CallExpr)
+	ignored := func() bool { return call != nil && v.ignoreCall(call) }
+
+	if stmt.Value == nil {
+		if !ignored() {
+			v.addErrorAtPosition(stmt.X.Pos(), stmt.X.End(), call, KindUnchecked)
+		}
+		return
+	}
+	if id, ok := stmt.Value.(*ast.Ident); ok && v.blank && id.Name == "_" && !ignored() {
+		v.addErrorAtPosition(id.NamePos, id.End(), call, KindBlank)
+	}
+}
+
+// yieldsError reports whether expr is an iterator function whose yield
+// function takes an error as its second argument.
+func (v *visitor) yieldsError(expr ast.Expr) bool {
+	t := v.typesInfo.TypeOf(expr)
+	if t == nil {
+		return false
+	}
+	sig, ok := t.Underlying().(*types.Signature)
+	if !ok || sig.Params().Len() != 1 {
+		return false
+	}
+	yield, ok := sig.Params().At(0).Type().Underlying().(*types.Signature)
+	if !ok || yield.Params().Len() != 2 {
+		return false
+	}
+	return isErrorResult(yield.Params().At(1).Type())
+}
+
 func (v *visitor) checkAssertExpr(expr *ast.TypeAssertExpr) {
 	if !v.asserts {
 		return
@@ -707,7 +1376,7 @@ func (v *visitor) checkAssertExpr(expr *ast.TypeAssertExpr) {
 		// type switch
 		return
 	}
-	v.addErrorAtPosition(expr.Pos(), nil)
+	v.addErrorAtPosition(expr.Pos(), expr.End(), nil, KindAssert)
 }
 
 func isErrorType(t types.Type) bool {
@@ -2,9 +2,13 @@ package errcheck
 
 import (
 	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 
@@ -17,6 +21,10 @@ var (
 	uncheckedMarkers map[marker]bool
 	blankMarkers     map[marker]bool
 	assertMarkers    map[marker]bool
+	unreadMarkers    map[marker]bool
+	shadowMarkers    map[marker]bool
+	receiveMarkers   map[marker]bool
+	directiveMarkers map[marker]bool
 )
 
 type marker struct {
@@ -36,6 +44,10 @@ func init() {
 	uncheckedMarkers = make(map[marker]bool)
 	blankMarkers = make(map[marker]bool)
 	assertMarkers = make(map[marker]bool)
+	unreadMarkers = make(map[marker]bool)
+	shadowMarkers = make(map[marker]bool)
+	receiveMarkers = make(map[marker]bool)
+	directiveMarkers = make(map[marker]bool)
 
 	cfg := &packages.Config{
 		Mode:  packages.NeedSyntax | packages.NeedTypes,
@@ -58,6 +70,14 @@ func init() {
 					blankMarkers[m] = true
 				case "ASSERT\n":
 					assertMarkers[m] = true
+				case "UNREAD\n":
+					unreadMarkers[m] = true
+				case "SHADOWED\n":
+					shadowMarkers[m] = true
+				case "RECEIVE\n":
+					receiveMarkers[m] = true
+				case "DIRECTIVE\n":
+					directiveMarkers[m] = true
 				}
 			}
 		}
@@ -69,6 +89,9 @@ type flags uint
 const (
 	CheckAsserts flags = 1 << iota
 	CheckBlank
+	CheckUnread
+	CheckShadow
+	CheckReceive
 )
 
 // TestUnchecked runs a test against the example files and ensures all unchecked errors are caught.
@@ -81,9 +104,349 @@ func TestBlank(t *testing.T) {
 	test(t, CheckBlank)
 }
 
+// TestUnread is like TestUnchecked but also ensures errors assigned to variables that are never read are caught.
+func TestUnread(t *testing.T) {
+	test(t, CheckUnread)
+}
+
+// TestShadowed is like TestUnchecked but also ensures error variables shadowing unread errors are caught.
+func TestShadowed(t *testing.T) {
+	test(t, CheckShadow)
+}
+
+// TestReceive is like TestUnchecked but also ensures errors received from channels and discarded are caught.
+func TestReceive(t *testing.T) {
+	test(t, CheckReceive)
+}
+
 func TestAll(t *testing.T) {
 	// TODO: CheckAsserts should work independently of CheckBlank
-	test(t, CheckAsserts|CheckBlank)
+	test(t, CheckAsserts|CheckBlank|CheckUnread|CheckShadow|CheckReceive)
+}
+
+func TestUsage(t *testing.T) {
+	var checker Checker
+	checker.Exclusions.Symbols = []string{"fmt.Println", "(*bytes.Buffer).Write", "fmt.Sscan"}
+	checker.Exclusions.Packages = []string{"io", "encoding/xml"}
+	checker.Exclusions.SymbolRegexpsByPackage = map[string]*regexp.Regexp{
+		"os": regexp.MustCompile("ReadFile"),
+		"":   regexp.MustCompile("^noSuchFunction$"),
+	}
+	packages, err := checker.LoadPackages(testPackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Result{}
+	for _, pkg := range packages {
+		result.Append(checker.CheckPackage(pkg))
+	}
+	usage := result.Unique().Usage
+
+	for sym, want := range map[string]bool{"fmt.Println": true, "(*bytes.Buffer).Write": true, "fmt.Sscan": false} {
+		if got := usage.Symbols[sym]; got != want {
+			t.Errorf("symbol %s: used = %v, want %v", sym, got, want)
+		}
+	}
+	for pkg, want := range map[string]bool{"io": true, "encoding/xml": false} {
+		if got := usage.Packages[pkg]; got != want {
+			t.Errorf("package %s: used = %v, want %v", pkg, got, want)
+		}
+	}
+	for pkg, want := range map[string]bool{"os": true, "": false} {
+		if got := usage.Regexps[pkg]; got != want {
+			t.Errorf("regexp for %q: used = %v, want %v", pkg, got, want)
+		}
+	}
+
+	seen := map[token.Position]bool{}
+	for _, d := range usage.Directives {
+		if seen[d.Pos] {
+			t.Errorf("directive at %s listed twice", d.Pos)
+		}
+		seen[d.Pos] = true
+		if want := !strings.Contains(d.Text, "stale"); d.Used != want {
+			t.Errorf("directive %q at %s: used = %v, want %v", d.Text, d.Pos, d.Used, want)
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("got %d directives, want 4", len(seen))
+	}
+
+	excluded := map[ExcludedCall]bool{}
+	for _, c := range usage.Excluded {
+		if excluded[c] {
+			t.Errorf("excluded call %+v listed twice", c)
+		}
+		excluded[c] = true
+		switch {
+		case c.FuncName == "fmt.Println" && c.Symbol != "fmt.Println",
+			c.FuncName == "os.ReadFile" && (c.Package != "os" || c.Regexp != "ReadFile"),
+			strings.HasPrefix(c.FuncName, "io.") && (c.Package != "io" || c.Regexp != ""):
+			t.Errorf("call of %s at %s excluded by %+v", c.FuncName, c.Pos, c)
+		}
+	}
+	for _, name := range []string{"fmt.Println", "os.ReadFile", "(*bytes.Buffer).Write"} {
+		if !slices.ContainsFunc(usage.Excluded, func(c ExcludedCall) bool { return c.FuncName == name }) {
+			t.Errorf("no excluded call of %s", name)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	no := false
+	var checker Checker
+	checker.Exclusions.BlankAssignments = true
+	checker.Exclusions.Symbols = DefaultExcludedSymbols
+	checker.Exclusions.Scopes = []Scope{
+		{Name: "scoped", Files: "testdata/scoped.go", Symbols: []string{"os.Remove"}, BlankAssignments: &no},
+		{Packages: "example.com/other/...", Symbols: []string{"os.ReadFile"}},
+	}
+	packages, err := checker.LoadPackages(testPackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Result{}
+	for _, pkg := range packages {
+		result.Append(checker.CheckPackage(pkg))
+	}
+
+	var blanks int
+	for _, e := range result.Unique().UncheckedErrors {
+		if e.Package != testPackage {
+			t.Errorf("error at %s has package %q, want %q", e.Pos, e.Package, testPackage)
+		}
+		inScope := strings.HasSuffix(e.Pos.Filename, "scoped.go")
+		if inScope && e.Kind == KindUnchecked {
+			t.Errorf("unexpected error excluded by the scope at %s", e.Pos)
+		}
+		if inScope != (e.Scope == "scoped") {
+			t.Errorf("error at %s has scope %q", e.Pos, e.Scope)
+		}
+		if e.Kind == KindBlank {
+			if !inScope {
+				t.Errorf("unexpected blank error outside of the scope at %s", e.Pos)
+			}
+			blanks++
+		}
+		if e.FuncName == "os.ReadFile" && e.Scope != "" {
+			t.Errorf("unexpected scope %q at %s", e.Scope, e.Pos)
+		}
+	}
+	if blanks != 1 {
+		t.Errorf("got %d blank errors, want 1", blanks)
+	}
+}
+
+func TestNegatedExclusions(t *testing.T) {
+	var checker Checker
+	checker.Exclusions.Symbols = append(DefaultExcludedSymbols[:len(DefaultExcludedSymbols):len(DefaultExcludedSymbols)],
+		"!(*bytes.Buffer).Write",
+		"!math/rand.Read",
+		"!(*io.PipeReader).*",
+	)
+	// Negated entries take precedence over package exclusions as well.
+	checker.Exclusions.Packages = []string{"math/rand"}
+	packages, err := checker.LoadPackages(testPackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Result{}
+	for _, pkg := range packages {
+		result.Append(checker.CheckPackage(pkg))
+	}
+	result = result.Unique()
+
+	got := map[string]int{}
+	for _, e := range result.UncheckedErrors {
+		if strings.HasSuffix(e.Pos.Filename, "main.go") {
+			got[e.FuncName]++
+		}
+	}
+	for name, want := range map[string]int{
+		"(*bytes.Buffer).Write":           2,
+		"(*bytes.Buffer).WriteString":     0,
+		"math/rand.Read":                  2,
+		"(*io.PipeReader).CloseWithError": 1,
+		"(*io.PipeWriter).CloseWithError": 0,
+	} {
+		if got[name] != want {
+			t.Errorf("got %d errors for %s, want %d", got[name], name, want)
+		}
+	}
+	for _, sym := range []string{"!(*bytes.Buffer).Write", "!math/rand.Read", "!(*io.PipeReader).*"} {
+		if !result.Usage.Symbols[sym] {
+			t.Errorf("expected %s to be used", sym)
+		}
+	}
+}
+
+func TestValidateSymbols(t *testing.T) {
+	var checker Checker
+	pkgs, err := checker.LoadPackages(testPackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		entry      string
+		ok         bool
+		suggestion string
+	}{
+		{entry: "fmt.Println", ok: true},
+		{entry: "!crypto/rand.Read", ok: true},
+		{entry: "(*bytes.Buffer).Write", ok: true},
+		{entry: "(io.Writer).Write", ok: true},
+		{entry: "(*bytes.Buffer).*", ok: true},
+		{entry: "io.Copy(io.Discard, *)", ok: true},
+		{entry: "io.Copy(*bytes.Buffer)", ok: true},
+		{entry: "fmt.Fprintf(net/http.ResponseWriter)", ok: true},
+		{entry: "fmt.Fprintf(error, 0, \"x\", nil)", ok: true},
+		{entry: "encoding/json.NewEncoder(net/http.ResponseWriter).Encode", ok: true},
+		{entry: "(*net/http.client).Do", suggestion: "(*net/http.Client).Do"},
+		{entry: "(net/http.Client).Do", suggestion: "(*net/http.Client).Do"},
+		{entry: "(bytes.Buffer).String", suggestion: "(*bytes.Buffer).String"},
+		{entry: "(*io.Writer).Write", suggestion: "(io.Writer).Write"},
+		{entry: "(*bufio.ReadWriter).Write", suggestion: "(*bufio.Writer).Write"},
+		{entry: "(*bytes.Buffer).Writ", suggestion: "(*bytes.Buffer).Write"},
+		{entry: "!net/htp.Get", suggestion: "!net/http.Get"},
+		{entry: "net/htp/...", suggestion: "net/http/..."},
+		{entry: "fmt.Prinln", suggestion: "fmt.Println"},
+		{entry: "os.Stderr"},
+		{entry: "fmt.Fprintf(os.Stdrr)", suggestion: "fmt.Fprintf(os.Stderr)"},
+		{entry: "io.Copy(*os.Stderr)"},
+		{entry: "io.Copy(*bytes.Bufer, *)", suggestion: "io.Copy(*bytes.Buffer, *)"},
+		{entry: "fmt.Fprintf(net/htp.ResponseWriter)", suggestion: "fmt.Fprintf(net/http.ResponseWriter)"},
+		{entry: "fmt.Fprintf(strng)", suggestion: "fmt.Fprintf(string)"},
+		{entry: "encoding/json.NewEncoder(*).Encod", suggestion: "encoding/json.NewEncoder(*).Encode"},
+		{entry: "example.com/nowhere.F"},
+		{entry: "hello()"},
+		{entry: "fmt.Println(", ok: false},
+	}
+
+	for _, c := range cases {
+		problems, err := checker.ValidateSymbols([]string{c.entry}, pkgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ok {
+			if len(problems) != 0 {
+				t.Errorf("%s: unexpected problems %v", c.entry, problems)
+			}
+			continue
+		}
+		if len(problems) != 1 {
+			t.Errorf("%s: got %d problems, want 1", c.entry, len(problems))
+			continue
+		}
+		if p := problems[0]; p.Suggestion != c.suggestion {
+			t.Errorf("%s: got suggestion %q, want %q (%s)", c.entry, p.Suggestion, c.suggestion, p.Message)
+		}
+	}
+}
+
+// TestValidateSymbolsImports validates entries that name packages the checked
+// package does not import directly, whose scopes the checked packages hold
+// only in part.
+func TestValidateSymbolsImports(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod":  "module example.com/v\n\ngo 1.22\n",
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	var checker Checker
+	pkgs, err := checker.LoadPackages(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		entry string
+		ok    bool
+	}{
+		// os is imported by fmt, but not by the checked package.
+		{"os.Remove", true},
+		{"(*os.File).Chmod", true},
+		{"io.Copy(*os.File)", true},
+		{"os.Remov", false},
+		// net/http is not imported at all.
+		{"(*net/http.Client).Do", true},
+		{"(*net/http.Client).Dont", false},
+		{"example.com/nowhere.F", false},
+	}
+	for _, c := range cases {
+		problems, err := checker.ValidateSymbols([]string{c.entry}, pkgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok := len(problems) == 0; ok != c.ok {
+			t.Errorf("%s: got problems %v, want ok = %v", c.entry, problems, c.ok)
+		}
+	}
+}
+
+// TestValidateSymbolsBuildFlags validates entries that name a package whose
+// files are built only with a build tag.
+func TestValidateSymbolsBuildFlags(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod":        "module example.com/v\n\ngo 1.22\n",
+		"main.go":       "package main\n\nfunc main() {}\n",
+		"tagged/f.go":   "//go:build foo\n\npackage tagged\n\nfunc F() error { return nil }\n",
+		"tagged/doc.go": "package tagged\n",
+	} {
+		writeFile(t, filepath.Join(dir, name), src)
+	}
+	t.Chdir(dir)
+
+	var checker Checker
+	pkgs, err := checker.LoadPackages(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []string{"example.com/v/tagged.F"}
+	if problems, err := checker.ValidateSymbols(entries, pkgs); err != nil || len(problems) != 1 {
+		t.Errorf("without tags: got problems %v, %v; want one", problems, err)
+	}
+	checker.Tags = []string{"foo"}
+	if problems, err := checker.ValidateSymbols(entries, pkgs); err != nil || len(problems) != 0 {
+		t.Errorf("with tags: got problems %v, %v; want none", problems, err)
+	}
+
+	checker.Mod = "bogus"
+	if _, err := checker.ValidateSymbols(entries, pkgs); err == nil {
+		t.Errorf("expected an error for an invalid -mod")
+	}
+}
+
+func TestExcludedPaths(t *testing.T) {
+	var checker Checker
+	checker.Exclusions.Symbols = DefaultExcludedSymbols
+	checker.Exclusions.Paths = []string{"testdata/args.go", "**/*lobs.go"}
+	packages, err := checker.LoadPackages(testPackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Result{}
+	for _, pkg := range packages {
+		result.Append(checker.CheckPackage(pkg))
+	}
+	var files int
+	for _, e := range result.Unique().UncheckedErrors {
+		switch path.Base(e.Pos.Filename) {
+		case "args.go", "globs.go":
+			t.Errorf("unexpected error in excluded file at %s", e.Pos)
+		case "main.go":
+			files++
+		}
+	}
+	if files == 0 {
+		t.Errorf("expected errors in files that are not excluded")
+	}
 }
 
 func TestBuildTags(t *testing.T) {
@@ -375,6 +738,11 @@ require github.com/testlog v0.0.0
 				if len(uerr.UncheckedErrors) != 0 {
 					t.Errorf("expected no errors, but got: %v", uerr)
 				}
+				for pkg := range test.ignore {
+					if !uerr.Usage.Regexps[pkg] {
+						t.Errorf("expected the regexp for %q to be used", pkg)
+					}
+				}
 				return
 			}
 
@@ -385,6 +753,145 @@ require github.com/testlog v0.0.0
 	}
 }
 
+func TestAnyMajorExclusions(t *testing.T) {
+	if os.Getenv("GO111MODULE") == "off" {
+		t.Skip("major version suffixes require modules")
+	}
+	files := map[string]string{
+		"go.mod": `module github.com/testmajor
+
+require github.com/testlog/v3 v3.0.0
+
+replace github.com/testlog/v3 => ./testlog
+`,
+		"main.go": `package main
+
+import "github.com/testlog/v3"
+
+func main() {
+	c := testlog.New()
+	c.Close()
+	testlog.Use(c)
+	testlog.Use(nil)
+	testlog.Info()
+}
+`,
+		"testlog/go.mod": "module github.com/testlog/v3\n",
+		"testlog/testlog.go": `package testlog
+
+type Client struct{}
+
+func New() *Client           { return &Client{} }
+func (*Client) Close() error { return nil }
+func Use(c *Client) error    { return nil }
+func Info() error            { return nil }
+`,
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.MkdirAll(path.Dir(path.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saveLoadPackages := loadPackages
+	defer func() { loadPackages = saveLoadPackages }()
+	loadPackages = func(cfg *packages.Config, paths ...string) ([]*packages.Package, error) {
+		cfg.Dir = dir
+		return packages.Load(cfg, paths...)
+	}
+
+	var checker Checker
+	checker.Exclusions.Symbols = []string{
+		"(*github.com/testlog@any-major.Client).Close",
+		"github.com/testlog@any-major.Use(*github.com/testlog@any-major.Client)",
+		"github.com/testlog/v2.Info",
+	}
+	pkgs, err := checker.LoadPackages("github.com/testmajor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Result{}
+	for _, pkg := range pkgs {
+		result.Append(checker.CheckPackage(pkg))
+	}
+
+	var got []string
+	for _, e := range result.Unique().UncheckedErrors {
+		got = append(got, e.Line)
+	}
+	if want := []string{"testlog.Use(nil)", "testlog.Info()"}; !slices.Equal(got, want) {
+		t.Errorf("got errors %q, want %q", got, want)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	cases := []struct {
+		name      string
+		src       string
+		markers   []string
+		paths     []string
+		generated bool
+	}{
+		{
+			name:      "standard.go",
+			src:       "// Code generated by sqlc. DO NOT EDIT.\n\npackage p\n",
+			generated: true,
+		},
+		{
+			name: "after_package.go",
+			src:  "package p\n\n// Code generated by sqlc. DO NOT EDIT.\n",
+		},
+		{
+			name: "gogo.go",
+			src:  "// Code generated by protoc-gen-gogo.\n// source: x.proto\n\npackage p\n",
+		},
+		{
+			name:      "gogo.go",
+			src:       "// Code generated by protoc-gen-gogo.\n// source: x.proto\n\npackage p\n",
+			markers:   []string{"Code generated by protoc-gen-gogo"},
+			generated: true,
+		},
+		{
+			name:      "swagger.go",
+			src:       "/*\nPackage p\n\nThis file was generated by the swagger tool.\n*/\npackage p\n",
+			markers:   []string{"This file was generated by the swagger tool"},
+			generated: true,
+		},
+		{
+			name:    "marker_after_package.go",
+			src:     "package p\n\n// This file was generated by the swagger tool.\n",
+			markers: []string{"This file was generated by the swagger tool"},
+		},
+		{
+			name:      "/src/m/api/x.pb.go",
+			src:       "package p\n",
+			paths:     []string{"**/*.pb.go"},
+			generated: true,
+		},
+		{
+			name:  "/src/m/api/x.go",
+			src:   "package p\n",
+			paths: []string{"**/*.pb.go"},
+		},
+	}
+
+	for _, c := range cases {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, c.name, c.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := Exclusions{GeneratedMarkers: c.markers, GeneratedPaths: c.paths}
+		if got := e.isGenerated(c.name, f); got != c.generated {
+			t.Errorf("%s with markers %q and paths %q: generated = %v, want %v", c.name, c.markers, c.paths, got, c.generated)
+		}
+	}
+}
+
 func TestWithoutGeneratedCode(t *testing.T) {
 	const testVendorGoMod = `module github.com/testvendor
 
@@ -500,26 +1007,48 @@ func test(t *testing.T, f flags) {
 	var (
 		asserts bool = f&CheckAsserts != 0
 		blank   bool = f&CheckBlank != 0
+		unread  bool = f&CheckUnread != 0
+		shadow  bool = f&CheckShadow != 0
+		receive bool = f&CheckReceive != 0
 	)
 	var checker Checker
 	checker.Exclusions.TypeAssertions = !asserts
 	checker.Exclusions.BlankAssignments = !blank
+	checker.Exclusions.CheckUnread = unread
+	checker.Exclusions.CheckShadowed = shadow
+	checker.Exclusions.CheckReceives = receive
+	checker.Exclusions.NolintDirectives = true
 	checker.Exclusions.Symbols = append(checker.Exclusions.Symbols, DefaultExcludedSymbols...)
 	checker.Exclusions.Symbols = append(checker.Exclusions.Symbols,
 		fmt.Sprintf("(%s.ErrorMakerInterface).MakeNilError", testPackage),
+		fmt.Sprintf("(%s.store).excludedRows", testPackage),
+		fmt.Sprintf("(*%s.logger).Write*", testPackage),
+		"io.Copy(io.Discard, *)",
+		"fmt.Fprintf(net/http.ResponseWriter)",
+		"encoding/json.NewEncoder(net/http.ResponseWriter).Encode",
+		fmt.Sprintf("(%s.sink).Flush(0)", testPackage),
 	)
 	packages, err := checker.LoadPackages(testPackage)
 	if err != nil {
 		t.Fatal(err)
 	}
 	uerr := Result{}
-	numErrors := len(uncheckedMarkers)
+	numErrors := len(uncheckedMarkers) + len(directiveMarkers)
 	if blank {
 		numErrors += len(blankMarkers)
 	}
 	if asserts {
 		numErrors += len(assertMarkers)
 	}
+	if unread {
+		numErrors += len(unreadMarkers)
+	}
+	if shadow {
+		numErrors += len(shadowMarkers)
+	}
+	if receive {
+		numErrors += len(receiveMarkers)
+	}
 
 	for _, pkg := range packages {
 		err := checker.CheckPackage(pkg)
@@ -539,6 +1068,15 @@ func test(t *testing.T, f flags) {
 			}
 			t.Errorf("Expected unchecked at %s", k)
 		}
+	directive_loop:
+		for k := range directiveMarkers {
+			for _, e := range uerr.UncheckedErrors {
+				if newMarker(e) == k {
+					continue directive_loop
+				}
+			}
+			t.Errorf("Expected directive at %s", k)
+		}
 		if blank {
 		blank_loop:
 			for k := range blankMarkers {
@@ -561,13 +1099,49 @@ func test(t *testing.T, f flags) {
 				t.Errorf("Expected assert at %s", k)
 			}
 		}
+		if unread {
+		unread_loop:
+			for k := range unreadMarkers {
+				for _, e := range uerr.UncheckedErrors {
+					if newMarker(e) == k {
+						continue unread_loop
+					}
+				}
+				t.Errorf("Expected unread at %s", k)
+			}
+		}
+		if shadow {
+		shadow_loop:
+			for k := range shadowMarkers {
+				for _, e := range uerr.UncheckedErrors {
+					if newMarker(e) == k {
+						continue shadow_loop
+					}
+				}
+				t.Errorf("Expected shadowed at %s", k)
+			}
+		}
+		if receive {
+		receive_loop:
+			for k := range receiveMarkers {
+				for _, e := range uerr.UncheckedErrors {
+					if newMarker(e) == k {
+						continue receive_loop
+					}
+				}
+				t.Errorf("Expected receive at %s", k)
+			}
+		}
 	}
 
 	for i, err := range uerr.UncheckedErrors {
 		m := marker{err.Pos.Filename, err.Pos.Line}
-		if !uncheckedMarkers[m] && !blankMarkers[m] && !assertMarkers[m] {
+		if !uncheckedMarkers[m] && !blankMarkers[m] && !assertMarkers[m] && !unreadMarkers[m] && !shadowMarkers[m] && !receiveMarkers[m] && !directiveMarkers[m] {
 			t.Errorf("%d: unexpected error: %v", i, err)
 		}
+		if err.Kind == KindShadowed && (err.Related.Filename != err.Pos.Filename || err.Related.Line >= err.Pos.Line) {
+			t.Errorf("%d: shadowed declaration of %v reported at %v", i, err, err.Related)
+		}
 		if err.SelectorName != "" && !strings.Contains(err.Line, err.SelectorName) {
 			t.Errorf("the line '%s' must contain the selector '%s'", err.Line, err.SelectorName)
 		}
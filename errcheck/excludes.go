@@ -3,7 +3,13 @@ package errcheck
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -63,28 +69,141 @@ var DefaultExcludedSymbols = []string{
 // ReadExcludes reads an excludes file, a newline delimited file that lists
 // patterns for which to allow unchecked errors.
 //
-// Lines that start with two forward slashes are considered comments and are ignored.
+// Lines that start with two forward slashes are considered comments and are
+// ignored, as is everything from two forward slashes that follow an entry
+// after a space. Such a trailing comment documents the reason for the entry.
+//
+// A line of the form "include PATH" reads the entries of another excludes
+// file. A relative PATH is relative to the directory of the file that
+// includes it.
+//
+// An error that gives the file and line is returned for entries that cannot
+// be parsed.
 func ReadExcludes(path string) ([]string, error) {
+	entries, err := ReadExcludeEntries(path)
+	if err != nil {
+		return nil, err
+	}
+
 	var excludes []string
+	for _, e := range entries {
+		excludes = append(excludes, e.Symbol)
+	}
+	return excludes, nil
+}
+
+// ExcludeEntry is a pattern read from an excludes file, along with where it
+// was read from and why it was added.
+type ExcludeEntry struct {
+	Symbol string
+
+	// Pos holds the file name and line of the entry.
+	Pos token.Position
+
+	// Reason is the trailing comment of the entry, if any.
+	Reason string
+}
+
+// ReadExcludeEntries is like ReadExcludes, but also returns the position and
+// reason of each pattern. The entries of included files are returned in
+// place of the include line.
+func ReadExcludeEntries(path string) ([]ExcludeEntry, error) {
+	return readExcludeEntries(path, nil)
+}
+
+// readExcludeEntries reads the excludes file at path, which has been
+// included by the files in stack.
+func readExcludeEntries(path string, stack []string) ([]ExcludeEntry, error) {
+	var entries []ExcludeEntry
 
 	buf, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	stack = append(stack, path)
 
 	scanner := bufio.NewScanner(bytes.NewReader(buf))
 
+	line := 0
 	for scanner.Scan() {
-		name := scanner.Text()
+		line++
+		text := strings.TrimSpace(scanner.Text())
 		// Skip comments and empty lines.
-		if strings.HasPrefix(name, "//") || name == "" {
+		if strings.HasPrefix(text, "//") || text == "" {
 			continue
 		}
-		excludes = append(excludes, name)
+		name, reason := splitExcludeComment(text)
+
+		if include, ok := strings.CutPrefix(name, "include "); ok {
+			include = strings.TrimSpace(include)
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			if slices.ContainsFunc(stack, func(p string) bool { return sameFile(p, include) }) {
+				return nil, fmt.Errorf("%s:%d: include cycle: %s", path, line, include)
+			}
+			included, err := readExcludeEntries(include, stack)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+				}
+				return nil, err
+			}
+			entries = append(entries, included...)
+			continue
+		}
+
+		if _, err := parseSymbol(name); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid exclude entry %q: %v", path, line, name, err)
+		}
+		entries = append(entries, ExcludeEntry{
+			Symbol: name,
+			Pos:    token.Position{Filename: path, Line: line},
+			Reason: reason,
+		})
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
 
-	return excludes, nil
+	return entries, nil
+}
+
+// splitExcludeComment splits a line of an excludes file into the entry and
+// its trailing comment. The comment starts at the first "//" that follows
+// white space outside of a string literal.
+func splitExcludeComment(line string) (entry, comment string) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '/' && strings.HasPrefix(line[i:], "//") && i > 0 && (line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+2:])
+		}
+	}
+	return line, ""
+}
+
+// sameFile reports whether the paths name the same file.
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fa, fb)
 }
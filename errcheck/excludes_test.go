@@ -1,7 +1,11 @@
 package errcheck
 
 import (
+	"go/token"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +25,20 @@ func TestReadExcludes(t *testing.T) {
 	}
 }
 
+func TestReadExcludeEntries(t *testing.T) {
+	entries, err := ReadExcludeEntries("testdata/excludes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ExcludeEntry{
+		{Symbol: "hello()", Pos: token.Position{Filename: "testdata/excludes.txt", Line: 2}},
+		{Symbol: "world()", Pos: token.Position{Filename: "testdata/excludes.txt", Line: 5}},
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Fatalf("got %#v, want %#v", entries, expected)
+	}
+}
+
 func TestReadEmptyExcludes(t *testing.T) {
 	excludes, err := ReadExcludes("testdata/empty_excludes.txt")
 	if err != nil {
@@ -37,3 +55,84 @@ func TestReadExcludesMissingFile(t *testing.T) {
 		t.Fatal("expected non-nil err, got nil")
 	}
 }
+
+func TestReadExcludesInvalidEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "excludes.txt")
+	if err := os.WriteFile(path, []byte("fmt.Println\nio.Copy(io.Discard, *\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadExcludes(path)
+	if err == nil || !strings.Contains(err.Error(), path+":2:") {
+		t.Fatalf("expected an error for line 2, got %v", err)
+	}
+}
+
+func TestReadExcludeEntriesIncludes(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared", "excludes.txt")
+	if err := os.MkdirAll(filepath.Dir(shared), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shared, []byte("// Organization-wide excludes.\n(*bytes.Buffer).Write // never fails\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "excludes.txt")
+	content := `include shared/excludes.txt
+  fmt.Println   // terminal output
+example.com/db.Open(*).Exec("http://example.com") // URL in a literal
+os.Remove//not a comment
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadExcludeEntries(path)
+	if err == nil {
+		t.Fatalf("expected an error for line 4, got %#v", entries)
+	}
+	if !strings.Contains(err.Error(), path+":4:") {
+		t.Fatalf("expected an error for line 4, got %v", err)
+	}
+
+	content = strings.Replace(content, "os.Remove//not a comment", "os.Remove", 1)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ReadExcludeEntries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ExcludeEntry{
+		{Symbol: "(*bytes.Buffer).Write", Pos: token.Position{Filename: shared, Line: 2}, Reason: "never fails"},
+		{Symbol: "fmt.Println", Pos: token.Position{Filename: path, Line: 2}, Reason: "terminal output"},
+		{Symbol: `example.com/db.Open(*).Exec("http://example.com")`, Pos: token.Position{Filename: path, Line: 3}, Reason: "URL in a literal"},
+		{Symbol: "os.Remove", Pos: token.Position{Filename: path, Line: 4}},
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Fatalf("got %#v, want %#v", entries, expected)
+	}
+}
+
+func TestReadExcludeEntriesIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(a, []byte("fmt.Println\ninclude b.txt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("include ./a.txt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadExcludeEntries(a)
+	if err == nil || !strings.Contains(err.Error(), b+":1: include cycle") {
+		t.Errorf("expected an include cycle error for %s:1, got %v", b, err)
+	}
+
+	if err := os.WriteFile(b, []byte("include missing.txt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadExcludeEntries(a)
+	if err == nil || !strings.Contains(err.Error(), b+":1:") {
+		t.Errorf("expected an error for %s:1, got %v", b, err)
+	}
+}
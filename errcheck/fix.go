@@ -0,0 +1,798 @@
+package errcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// fixer suggests changes to the source of a file that handle the errors
+// found in it.
+type fixer struct {
+	pkg  *types.Package
+	info *types.Info
+	file *ast.File
+	tf   *token.File
+	src  []byte
+}
+
+// suggestedFixes returns the fixes for an unchecked error found in the file,
+// or nil if there is no safe rewrite.
+//
+// A call statement whose only error result is dropped, in a function whose
+// last result is an error, is turned into
+//
+//	if err := f(); err != nil {
+//		return 0, "", err
+//	}
+//
+// with the zero values of the other results of the function. The other
+// results of the call are discarded.
+//
+// A deferred call, such as defer f.Close(), is turned into a closure that
+// assigns its error to the error result of the function, which is named if
+// needed. See deferFixes.
+func (f *fixer) suggestedFixes(e UncheckedError) []analysis.SuggestedFix {
+	if e.Kind != KindUnchecked || e.Pos.Offset >= f.tf.Size() {
+		return nil
+	}
+	pos := f.tf.Pos(e.Pos.Offset)
+	stmt, parent, fn := f.enclosing(pos)
+	if stmt == nil || fn == nil || !inStmtList(parent) {
+		return nil
+	}
+	results := fn.sig.Results()
+	if results.Len() == 0 || !isError(results.At(results.Len()-1).Type()) {
+		return nil
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.ExprStmt:
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok || call.Lparen != pos {
+			return nil
+		}
+		if fix, ok := f.returnError(stmt, call, fn.sig); ok {
+			return []analysis.SuggestedFix{fix}
+		}
+	case *ast.DeferStmt:
+		if stmt.Call.Lparen == pos {
+			return f.deferFixes(stmt, fn)
+		}
+	}
+	return nil
+}
+
+// enclosing returns the innermost call, defer or go statement that contains
+// pos, the node that contains the statement, and the function that contains
+// it.
+func (f *fixer) enclosing(pos token.Pos) (ast.Stmt, ast.Node, *enclosingFunc) {
+	path, _ := astutil.PathEnclosingInterval(f.file, pos, pos)
+	var stmt ast.Stmt
+	var parent ast.Node
+	for i, n := range path {
+		switch n := n.(type) {
+		case *ast.ExprStmt, *ast.DeferStmt, *ast.GoStmt:
+			if stmt == nil && i+1 < len(path) {
+				stmt, parent = n.(ast.Stmt), path[i+1]
+			}
+		case *ast.FuncLit:
+			if sig, ok := f.info.TypeOf(n).(*types.Signature); ok {
+				return stmt, parent, &enclosingFunc{typ: n.Type, body: n.Body, sig: sig}
+			}
+		case *ast.FuncDecl:
+			if obj, ok := f.info.Defs[n.Name].(*types.Func); ok && n.Body != nil {
+				return stmt, parent, &enclosingFunc{typ: n.Type, body: n.Body, sig: obj.Type().(*types.Signature)}
+			}
+		}
+	}
+	return stmt, parent, nil
+}
+
+// inStmtList reports whether the statements that parent contains are in a
+// list, where a statement can be replaced by any other. The init and post
+// statements of if, for and switch statements are not.
+func inStmtList(parent ast.Node) bool {
+	switch parent.(type) {
+	case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+		return true
+	}
+	return false
+}
+
+// enclosingFunc is the function declaration or literal that contains an
+// unchecked error.
+type enclosingFunc struct {
+	typ  *ast.FuncType
+	body *ast.BlockStmt
+	sig  *types.Signature
+}
+
+// returnError returns the fix that checks the error returned by the call of
+// stmt and returns it from the function with signature sig.
+func (f *fixer) returnError(stmt *ast.ExprStmt, call *ast.CallExpr, sig *types.Signature) (analysis.SuggestedFix, bool) {
+	results := sig.Results()
+
+	// The call must return exactly one value of type error, which is bound
+	// to a fresh name.
+	var resultTypes []types.Type
+	switch t := f.info.TypeOf(call).(type) {
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			resultTypes = append(resultTypes, t.At(i).Type())
+		}
+	case nil:
+		return analysis.SuggestedFix{}, false
+	default:
+		resultTypes = []types.Type{t}
+	}
+	scope := f.scopeAt(stmt.Pos())
+	name := freshName(scope, stmt.Pos(), "err")
+	lhs := make([]string, len(resultTypes))
+	found := false
+	for i, t := range resultTypes {
+		if !isError(t) {
+			lhs[i] = "_"
+			continue
+		}
+		if found {
+			return analysis.SuggestedFix{}, false
+		}
+		lhs[i] = name
+		found = true
+	}
+	if !found {
+		return analysis.SuggestedFix{}, false
+	}
+
+	var values []string
+	for i := 0; i < results.Len()-1; i++ {
+		zero, ok := f.zeroValue(scope, stmt.Pos(), results.At(i).Type())
+		if !ok {
+			return analysis.SuggestedFix{}, false
+		}
+		values = append(values, zero)
+	}
+	values = append(values, name)
+
+	indent := f.indent(stmt.Pos())
+	return analysis.SuggestedFix{
+		Message: "Return the error",
+		TextEdits: []analysis.TextEdit{
+			{Pos: stmt.Pos(), End: stmt.Pos(), NewText: []byte("if " + strings.Join(lhs, ", ") + " := ")},
+			{Pos: stmt.End(), End: stmt.End(), NewText: []byte(fmt.Sprintf("; %s != nil {\n%s\treturn %s\n%s}",
+				name, indent, strings.Join(values, ", "), indent))},
+		},
+	}, true
+}
+
+// isError reports whether t is the error interface, or an alias of it.
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// scopeAt returns the innermost scope that contains pos.
+func (f *fixer) scopeAt(pos token.Pos) *types.Scope {
+	if f.pkg == nil {
+		return nil
+	}
+	return f.pkg.Scope().Innermost(pos)
+}
+
+// freshName returns name, followed by a number if needed, such that it does
+// not refer to any object visible at pos.
+func freshName(scope *types.Scope, pos token.Pos, name string) string {
+	fresh := name
+	for i := 1; scope != nil; i++ {
+		if _, obj := scope.LookupParent(fresh, pos); obj == nil {
+			break
+		}
+		fresh = name + strconv.Itoa(i)
+	}
+	return fresh
+}
+
+// indent returns the white space that the line containing pos starts with.
+func (f *fixer) indent(pos token.Pos) string {
+	start := f.tf.Offset(f.tf.LineStart(f.line(pos)))
+	end := start
+	for end < len(f.src) && (f.src[end] == '\t' || f.src[end] == ' ') {
+		end++
+	}
+	return string(f.src[start:end])
+}
+
+// lineAround returns the source that precedes n on its first line and the
+// source that follows it on its last line.
+func (f *fixer) lineAround(n ast.Node) (before, after string) {
+	start := f.tf.Offset(f.tf.LineStart(f.line(n.Pos())))
+	end := f.tf.Offset(n.End())
+	eol := end
+	for eol < len(f.src) && f.src[eol] != '\n' {
+		eol++
+	}
+	return string(f.src[start:f.tf.Offset(n.Pos())]), string(f.src[end:eol])
+}
+
+// line returns the line of pos in the file, regardless of //line
+// directives.
+func (f *fixer) line(pos token.Pos) int {
+	return f.tf.PositionFor(pos, false).Line
+}
+
+// zeroValue returns an expression for the zero value of t that is valid at
+// pos, or false if there is none.
+func (f *fixer) zeroValue(scope *types.Scope, pos token.Pos, t types.Type) (string, bool) {
+	if tp, ok := maybeUnalias(t).(*types.TypeParam); ok {
+		if _, obj := scope.LookupParent(tp.Obj().Name(), pos); obj != tp.Obj() {
+			return "", false
+		}
+		return "*new(" + tp.Obj().Name() + ")", true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	case *types.Struct, *types.Array:
+		expr, ok := f.typeExpr(scope, pos, t)
+		return expr + "{}", ok
+	}
+	return "", false
+}
+
+// typeExpr returns an expression that denotes t at pos, or false if some of
+// the names it refers to are not visible there.
+func (f *fixer) typeExpr(scope *types.Scope, pos token.Pos, t types.Type) (string, bool) {
+	ok := true
+	if named, isNamed := maybeUnalias(t).(*types.Named); isNamed && named.Obj().Pkg() == f.pkg {
+		if _, obj := scope.LookupParent(named.Obj().Name(), pos); obj != named.Obj() {
+			return "", false
+		}
+	}
+	expr := types.TypeString(t, func(p *types.Package) string {
+		if p == f.pkg {
+			return ""
+		}
+		name, found := f.importName(p.Path())
+		if !found {
+			ok = false
+			return p.Name()
+		}
+		if _, obj := scope.LookupParent(name, pos); obj == nil || !isPkgName(obj) {
+			ok = false
+		}
+		return name
+	})
+	return expr, ok
+}
+
+// importName returns the name under which the file imports the package with
+// the given path.
+func (f *fixer) importName(path string) (string, bool) {
+	for _, spec := range f.file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err != nil || p != path {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == "_" || spec.Name.Name == "." {
+				continue
+			}
+			return spec.Name.Name, true
+		}
+		if obj, ok := f.info.Implicits[spec].(*types.PkgName); ok {
+			return obj.Name(), true
+		}
+	}
+	return "", false
+}
+
+func isPkgName(obj types.Object) bool {
+	_, ok := obj.(*types.PkgName)
+	return ok
+}
+
+// deferFixes returns the fixes for a deferred call whose error is dropped, as
+// in defer f.Close(). The call is moved into a closure that assigns its error
+// to the error result of the function if that is nil,
+//
+//	defer func() {
+//		if cerr := f.Close(); err == nil {
+//			err = cerr
+//		}
+//	}()
+//
+// or joins the two with errors.Join. If the results of the function are not
+// named, the error result is named err and the others _.
+//
+// The closure evaluates the operands of the call when the function returns
+// rather than when the defer statement is executed, so no fix is suggested
+// if they may change in between.
+func (f *fixer) deferFixes(stmt *ast.DeferStmt, fn *enclosingFunc) []analysis.SuggestedFix {
+	call := stmt.Call
+	if t := f.info.TypeOf(call); t == nil || !isError(t) {
+		return nil
+	}
+	if !f.stableOperands(call, fn) {
+		return nil
+	}
+
+	scope := f.scopeAt(stmt.Pos())
+	resultEdits, name, ok := f.nameErrorResult(fn, scope, stmt.Pos())
+	if !ok {
+		return nil
+	}
+	cerr := freshName(scope, stmt.Pos(), "cerr")
+	callText := string(f.src[f.tf.Offset(call.Pos()):f.tf.Offset(call.End())])
+	indent := f.indent(stmt.Pos())
+	closure := func(lines ...string) []byte {
+		var b strings.Builder
+		b.WriteString("defer func() {\n")
+		for _, line := range lines {
+			b.WriteString(indent + "\t" + line + "\n")
+		}
+		b.WriteString(indent + "}()")
+		return []byte(b.String())
+	}
+
+	fixes := []analysis.SuggestedFix{{
+		Message: "Assign the deferred error to the error result if it is nil",
+		TextEdits: append(slices.Clip(resultEdits), analysis.TextEdit{
+			Pos: stmt.Pos(),
+			End: stmt.End(),
+			NewText: closure(
+				fmt.Sprintf("if %s := %s; %s == nil {", cerr, callText, name),
+				fmt.Sprintf("\t%s = %s", name, cerr),
+				"}"),
+		}),
+	}}
+	if errorsPkg, importEdits, ok := f.errorsJoin(scope, stmt.Pos()); ok {
+		fixes = append(fixes, analysis.SuggestedFix{
+			Message: "Join the deferred error with the error result",
+			TextEdits: slices.Concat(importEdits, resultEdits, []analysis.TextEdit{{
+				Pos:     stmt.Pos(),
+				End:     stmt.End(),
+				NewText: closure(fmt.Sprintf("%s = %s.Join(%s, %s)", name, errorsPkg, name, callText)),
+			}}),
+		})
+	}
+	return fixes
+}
+
+// nameErrorResult returns the name of the last result of fn, which is an
+// error, as it can be referred to at pos. If the result is not named, it
+// also returns the edits that name it.
+func (f *fixer) nameErrorResult(fn *enclosingFunc, scope *types.Scope, pos token.Pos) ([]analysis.TextEdit, string, bool) {
+	results := fn.typ.Results.List
+	last := results[len(results)-1]
+	if len(last.Names) > 0 {
+		id := last.Names[len(last.Names)-1]
+		if id.Name != "_" {
+			// The name may be shadowed at pos.
+			_, obj := scope.LookupParent(id.Name, pos)
+			return nil, id.Name, obj != nil && obj == f.info.Defs[id]
+		}
+		name := f.freshResultName(fn, scope, pos)
+		return []analysis.TextEdit{{Pos: id.Pos(), End: id.End(), NewText: []byte(name)}}, name, true
+	}
+
+	name := f.freshResultName(fn, scope, pos)
+	if fn.typ.Results.Opening == token.NoPos {
+		return []analysis.TextEdit{
+			{Pos: last.Type.Pos(), End: last.Type.Pos(), NewText: []byte("(" + name + " ")},
+			{Pos: last.Type.End(), End: last.Type.End(), NewText: []byte(")")},
+		}, name, true
+	}
+	var edits []analysis.TextEdit
+	for _, field := range results {
+		text := "_ "
+		if field == last {
+			text = name + " "
+		}
+		edits = append(edits, analysis.TextEdit{Pos: field.Type.Pos(), End: field.Type.Pos(), NewText: []byte(text)})
+	}
+	return edits, name, true
+}
+
+// freshResultName returns a name for the error result of fn that is not in
+// use at pos, nor anywhere else in the function block.
+func (f *fixer) freshResultName(fn *enclosingFunc, scope *types.Scope, pos token.Pos) string {
+	funcScope := f.info.Scopes[fn.typ]
+	for i := 0; ; i++ {
+		name := "err"
+		if i > 0 {
+			name += strconv.Itoa(i)
+		}
+		if _, obj := scope.LookupParent(name, pos); obj != nil {
+			continue
+		}
+		if funcScope != nil && funcScope.Lookup(name) != nil {
+			continue
+		}
+		return name
+	}
+}
+
+// stableOperands reports whether the operands of call are constants or
+// variables of fn that are not changed after they are declared, and so have
+// the same values when the function returns as when the defer statement is
+// executed. Calling a method with a pointer receiver on a variable changes it.
+func (f *fixer) stableOperands(call *ast.CallExpr, fn *enclosingFunc) bool {
+	var vars []*types.Var
+	stable := true
+	var visit func(expr ast.Expr)
+	visit = func(expr ast.Expr) {
+		switch expr := expr.(type) {
+		case *ast.Ident:
+			switch obj := f.info.Uses[expr].(type) {
+			case *types.Var:
+				// Package variables and those of enclosing functions may be
+				// changed elsewhere.
+				if obj.Pos() < fn.typ.Pos() || obj.Pos() >= fn.body.End() {
+					stable = false
+				}
+				vars = append(vars, obj)
+			case *types.Func, *types.PkgName, *types.Const, *types.Nil:
+			default:
+				stable = false
+			}
+		case *ast.SelectorExpr:
+			if id, ok := expr.X.(*ast.Ident); ok {
+				if _, isPkg := f.info.Uses[id].(*types.PkgName); isPkg {
+					visit(expr.Sel)
+					return
+				}
+			}
+			// A value receiver reached through a pointer is copied when the
+			// defer statement is executed, and may be changed through any
+			// other pointer to it afterwards.
+			if sel := f.info.Selections[expr]; sel != nil && sel.Kind() == types.MethodVal && isPointer(sel.Recv()) && !pointerReceiver(sel) {
+				stable = false
+			}
+			visit(expr.X)
+		case *ast.ParenExpr:
+			visit(expr.X)
+		case *ast.BasicLit:
+		default:
+			stable = false
+		}
+	}
+	visit(call.Fun)
+	for _, arg := range call.Args {
+		visit(arg)
+	}
+	if !stable {
+		return false
+	}
+
+	// Before Go 1.22, loop variables are shared by all iterations.
+	perIteration := f.goVersionAtLeast("go1.22")
+	ast.Inspect(fn.body, func(n ast.Node) bool {
+		var changed, loopVars []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			changed = n.Lhs
+		case *ast.IncDecStmt:
+			changed = []ast.Expr{n.X}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				changed = []ast.Expr{n.X}
+			}
+		case *ast.SelectorExpr:
+			// A method with a pointer receiver takes the address of an
+			// addressable operand implicitly.
+			if sel := f.info.Selections[n]; sel != nil && sel.Kind() == types.MethodVal && !isPointer(sel.Recv()) && pointerReceiver(sel) {
+				changed = []ast.Expr{n.X}
+			}
+		case *ast.RangeStmt:
+			changed = []ast.Expr{n.Key, n.Value}
+			if !perIteration {
+				loopVars = changed
+			}
+		case *ast.ForStmt:
+			if init, ok := n.Init.(*ast.AssignStmt); ok && !perIteration {
+				loopVars = init.Lhs
+			}
+		}
+		for _, expr := range changed {
+			// A write to a field, element or pointee changes the variable it
+			// is reached from.
+			if id := rootIdent(expr); id != nil && slices.Contains(vars, asVar(f.info.Uses[id])) {
+				stable = false
+			}
+		}
+		for _, expr := range loopVars {
+			if id, ok := expr.(*ast.Ident); ok && slices.Contains(vars, asVar(f.info.Defs[id])) {
+				stable = false
+			}
+		}
+		return stable
+	})
+	return stable
+}
+
+// rootIdent returns the variable that expr, an operand that is assigned to or
+// whose address is taken, is a part of, or nil if there is none.
+func rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// pointerReceiver reports whether the method selected by sel has a pointer
+// receiver.
+func pointerReceiver(sel *types.Selection) bool {
+	sig, ok := sel.Obj().Type().(*types.Signature)
+	return ok && sig.Recv() != nil && isPointer(sig.Recv().Type())
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := types.Unalias(t).Underlying().(*types.Pointer)
+	return ok
+}
+
+func asVar(obj types.Object) *types.Var {
+	v, _ := obj.(*types.Var)
+	return v
+}
+
+// goVersionAtLeast reports whether the file is compiled with the language
+// version v or later.
+func (f *fixer) goVersionAtLeast(v string) bool {
+	fileVersion := f.info.FileVersions[f.file]
+	if fileVersion == "" && f.pkg != nil {
+		fileVersion = f.pkg.GoVersion()
+	}
+	return fileVersion == "" || version.Compare(fileVersion, v) >= 0
+}
+
+// errorsJoin returns the name under which the errors package can be referred
+// to at pos, along with the edits that import it if needed. It returns false
+// if the name is taken, or errors.Join is not available.
+func (f *fixer) errorsJoin(scope *types.Scope, pos token.Pos) (string, []analysis.TextEdit, bool) {
+	if !f.goVersionAtLeast("go1.20") {
+		return "", nil, false
+	}
+	if name, ok := f.importName("errors"); ok {
+		_, obj := scope.LookupParent(name, pos)
+		return name, nil, obj != nil && isPkgName(obj)
+	}
+	if _, obj := scope.LookupParent("errors", pos); obj != nil {
+		return "", nil, false
+	}
+
+	var decl *ast.GenDecl
+	for _, d := range f.file.Decls {
+		if d, ok := d.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			decl = d
+			break
+		}
+	}
+	switch {
+	case decl == nil:
+		return "errors", []analysis.TextEdit{{Pos: f.file.Name.End(), End: f.file.Name.End(), NewText: []byte("\n\nimport \"errors\"")}}, true
+	case !decl.Lparen.IsValid():
+		return "errors", []analysis.TextEdit{{Pos: decl.Pos(), End: decl.Pos(), NewText: []byte("import \"errors\"\n")}}, true
+	}
+	// Keep the first group of imports, which usually holds those of the
+	// standard library, sorted.
+	last := decl.Specs[0].(*ast.ImportSpec)
+	for i, spec := range decl.Specs {
+		spec := spec.(*ast.ImportSpec)
+		if i > 0 && f.line(spec.Pos()) > f.line(last.End())+1 {
+			break
+		}
+		if path, _ := strconv.Unquote(spec.Path.Value); path > "errors" {
+			return "errors", []analysis.TextEdit{{Pos: spec.Pos(), End: spec.Pos(), NewText: []byte("\"errors\"\n\t")}}, true
+		}
+		last = spec
+	}
+	return "errors", []analysis.TextEdit{{Pos: last.End(), End: last.End(), NewText: []byte("\n\t\"errors\"")}}, true
+}
+
+// discard returns the edits that turn an unchecked error into an explicit
+// discard marked with a //errcheck:legacy directive, or false if the error is
+// not found in a call, defer or go statement.
+//
+// A call statement f() becomes _ = f(). The call of a defer or go statement is
+// wrapped in a closure, as in defer func() { _ = f() }(), unless its operands
+// may change before the closure is run, in which case the statement is only
+// marked.
+//
+// Calls in the header of an if, for or switch statement are not rewritten: a
+// directive on the line above the statement would suppress the findings in
+// its body too.
+func (f *fixer) discard(e UncheckedError) ([]analysis.TextEdit, bool) {
+	if e.Kind != KindUnchecked || e.Pos.Offset >= f.tf.Size() {
+		return nil, false
+	}
+	pos := f.tf.Pos(e.Pos.Offset)
+	stmt, parent, fn := f.enclosing(pos)
+	var call *ast.CallExpr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		call, _ = s.X.(*ast.CallExpr)
+	case *ast.DeferStmt:
+		call = s.Call
+	case *ast.GoStmt:
+		call = s.Call
+	}
+	if call == nil || call.Lparen != pos || fn == nil || !inStmtList(parent) {
+		return nil, false
+	}
+
+	blanks := "_"
+	if t, ok := f.info.TypeOf(call).(*types.Tuple); ok {
+		blanks = strings.Repeat("_, ", t.Len()-1) + "_"
+	}
+	// A directive that follows a statement spanning several lines, or that
+	// shares its line with other code, would not apply to the call alone, so
+	// it goes on a line of its own instead.
+	var prefix, suffix string
+	before, after := f.lineAround(stmt)
+	after = strings.TrimSpace(after)
+	if f.line(stmt.Pos()) != f.line(stmt.End()) || after != "" && !strings.HasPrefix(after, "//") {
+		prefix = legacyDirective + "\n" + f.indent(stmt.Pos())
+		if strings.TrimSpace(before) != "" {
+			prefix = "\n" + prefix
+		}
+	} else {
+		suffix = " " + legacyDirective
+	}
+	var edits []analysis.TextEdit
+	switch {
+	case stmt.Pos() == call.Pos():
+		// A call statement.
+		prefix += blanks + " = "
+	case f.stableOperands(call, fn):
+		edits = append(edits,
+			analysis.TextEdit{Pos: call.Pos(), End: call.Pos(), NewText: []byte("func() { " + blanks + " = ")},
+			analysis.TextEdit{Pos: call.End(), End: call.End(), NewText: []byte(" }()")})
+	}
+	if prefix != "" {
+		edits = append([]analysis.TextEdit{{Pos: stmt.Pos(), End: stmt.Pos(), NewText: []byte(prefix)}}, edits...)
+	}
+	if suffix != "" {
+		edits = append(edits, analysis.TextEdit{Pos: stmt.End(), End: stmt.End(), NewText: []byte(suffix)})
+	}
+	return edits, true
+}
+
+// Edit is a change to the source of a file, which replaces the bytes between
+// the offsets Start and End by NewText.
+type Edit struct {
+	Filename   string
+	Start, End int
+	NewText    []byte
+}
+
+// Fix is a change that handles an unchecked error. Its edits must be applied
+// together.
+type Fix struct {
+	Err     UncheckedError
+	Message string
+	Edits   []Edit
+}
+
+// SuggestedFixes returns the fixes for those of errs that were found in pkg,
+// using the first of the fixes that Analyzer suggests for each. It also
+// returns the other errors, including those found outside pkg, so that the
+// errors reported for several packages can be passed from one package to the
+// next.
+func SuggestedFixes(pkg *packages.Package, errs []UncheckedError) ([]Fix, []UncheckedError, error) {
+	return packageFixes(pkg, errs, func(f *fixer, e UncheckedError) (string, []analysis.TextEdit) {
+		fixes := f.suggestedFixes(e)
+		if len(fixes) == 0 {
+			return "", nil
+		}
+		return fixes[0].Message, fixes[0].TextEdits
+	})
+}
+
+// DiscardFixes returns the fixes that turn those of errs that were found in
+// call, defer and go statements of pkg into explicit discards, marked with
+// //errcheck:legacy directives:
+//
+//	_ = f() //errcheck:legacy
+//	defer func() { _ = f.Close() }() //errcheck:legacy
+//
+// Like SuggestedFixes, it also returns the other errors.
+func DiscardFixes(pkg *packages.Package, errs []UncheckedError) ([]Fix, []UncheckedError, error) {
+	return packageFixes(pkg, errs, func(f *fixer, e UncheckedError) (string, []analysis.TextEdit) {
+		edits, ok := f.discard(e)
+		if !ok {
+			return "", nil
+		}
+		return "Discard the error explicitly", edits
+	})
+}
+
+// packageFixes returns the fixes that fix returns for those of errs that were
+// found in pkg, and the other errors.
+func packageFixes(pkg *packages.Package, errs []UncheckedError, fix func(*fixer, UncheckedError) (string, []analysis.TextEdit)) ([]Fix, []UncheckedError, error) {
+	var fixes []Fix
+	var rest []UncheckedError
+	fixers := map[*ast.File]*fixer{}
+	for _, e := range errs {
+		file := fileOf(pkg, e.Pos)
+		if file == nil {
+			rest = append(rest, e)
+			continue
+		}
+		f, ok := fixers[file]
+		if !ok {
+			tf := pkg.Fset.File(file.Pos())
+			src, err := os.ReadFile(tf.Name())
+			if err != nil {
+				return nil, nil, err
+			}
+			f = &fixer{pkg: pkg.Types, info: pkg.TypesInfo, file: file, tf: tf, src: src}
+			fixers[file] = f
+		}
+		message, edits := fix(f, e)
+		if len(edits) == 0 {
+			rest = append(rest, e)
+			continue
+		}
+		fixes = append(fixes, Fix{Err: e, Message: message, Edits: f.edits(edits)})
+	}
+	return fixes, rest, nil
+}
+
+// edits converts text edits of the file into Edits.
+func (f *fixer) edits(textEdits []analysis.TextEdit) []Edit {
+	edits := make([]Edit, len(textEdits))
+	for i, te := range textEdits {
+		edits[i] = Edit{
+			Filename: f.tf.Name(),
+			Start:    f.tf.Offset(te.Pos),
+			End:      f.tf.Offset(te.End),
+			NewText:  te.NewText,
+		}
+	}
+	return edits
+}
+
+// fileOf returns the file of pkg that contains the position, which may be
+// adjusted by //line directives, or nil if there is none.
+func fileOf(pkg *packages.Package, position token.Position) *ast.File {
+	for _, file := range pkg.Syntax {
+		tf := pkg.Fset.File(file.Pos())
+		if position.Offset < tf.Size() && tf.Position(tf.Pos(position.Offset)) == position {
+			return file
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package errcheck
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Scope is a set of exclusion rules that applies only to some of the checked
+// files, selected by package path pattern, file glob or both.
+//
+// The settings of a scope override those of the enclosing Exclusions for the
+// files it applies to. If several scopes apply to a file, they are applied in
+// order, so that later scopes override earlier ones. Symbols are cumulative:
+// the entries of all the scopes that apply are added to Exclusions.Symbols.
+type Scope struct {
+	// Name identifies the scope in UncheckedError.Scope. It defaults to
+	// the package pattern or the file glob.
+	Name string
+
+	// Packages is a package path pattern, in which "..." matches any
+	// string. As with the go command, a pattern ending in "/..." also
+	// matches the package without the suffix, as in
+	// "example.com/m/cmd/tools/...".
+	Packages string
+
+	// Files is a glob pattern of the files the scope applies to, in the
+	// syntax of Exclusions.Paths, so "examples/**" applies to every file
+	// below the examples directory at the root of the module.
+	Files string
+
+	// Symbols lists additional exclusion entries, in the format of
+	// Exclusions.Symbols.
+	Symbols []string
+
+	// The following settings override those of Exclusions with the same
+	// name if they are not nil.
+	BlankAssignments *bool
+	TypeAssertions   *bool
+	CheckUnread      *bool
+	CheckShadowed    *bool
+	CheckReceives    *bool
+	NolintDirectives *bool
+}
+
+func (s *Scope) name() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Packages != "" && s.Files != "":
+		return s.Packages + " " + s.Files
+	case s.Packages != "":
+		return s.Packages
+	default:
+		return s.Files
+	}
+}
+
+// scopedExclusions resolves the exclusions that apply to each checked file.
+type scopedExclusions struct {
+	base   *Exclusions
+	scopes []scope
+
+	// matchers caches the symbol matchers for combinations of scopes, keyed
+	// by the indices of the scopes.
+	mu       sync.Mutex
+	matchers map[string]*symbolMatcher
+}
+
+type scope struct {
+	*Scope
+	packages *regexp.Regexp
+}
+
+func newScopedExclusions(e *Exclusions) *scopedExclusions {
+	s := &scopedExclusions{
+		base:     e,
+		matchers: make(map[string]*symbolMatcher),
+	}
+	for i := range e.Scopes {
+		sc := scope{Scope: &e.Scopes[i]}
+		if sc.Packages != "" {
+			sc.packages = packagePatternRegexp(sc.Packages)
+		}
+		s.scopes = append(s.scopes, sc)
+	}
+	return s
+}
+
+// configure sets up v to check a file of the package with the given path
+// according to the exclusions and scopes that apply to it.
+func (s *scopedExclusions) configure(v *visitor, pkgPath, filename string) {
+	e := *s.base
+	symbols := e.Symbols
+	var names []string
+	var key strings.Builder
+	for i, sc := range s.scopes {
+		if !sc.matches(pkgPath, filename) {
+			continue
+		}
+		names = append(names, sc.name())
+		fmt.Fprintf(&key, "%d,", i)
+
+		override(&e.BlankAssignments, sc.BlankAssignments)
+		override(&e.TypeAssertions, sc.TypeAssertions)
+		override(&e.CheckUnread, sc.CheckUnread)
+		override(&e.CheckShadowed, sc.CheckShadowed)
+		override(&e.CheckReceives, sc.CheckReceives)
+		override(&e.NolintDirectives, sc.NolintDirectives)
+		symbols = append(symbols[:len(symbols):len(symbols)], sc.Symbols...)
+	}
+
+	s.mu.Lock()
+	m, ok := s.matchers[key.String()]
+	if !ok {
+		m = newSymbolMatcher(symbols)
+		s.matchers[key.String()] = m
+	}
+	s.mu.Unlock()
+
+	v.blank = !e.BlankAssignments
+	v.asserts = !e.TypeAssertions
+	v.unread = e.CheckUnread
+	v.shadow = e.CheckShadowed
+	v.receives = e.CheckReceives
+	v.nolint = e.NolintDirectives
+	v.exclude = m
+	v.scope = strings.Join(names, ", ")
+}
+
+func override(setting *bool, value *bool) {
+	if value != nil {
+		*setting = *value
+	}
+}
+
+// matches reports whether the scope applies to a file of the package with
+// the given path. A scope without a package pattern or file glob applies
+// to every file.
+func (sc *scope) matches(pkgPath, filename string) bool {
+	if sc.packages != nil && !sc.packages.MatchString(strings.TrimSuffix(pkgPath, "_test")) {
+		return false
+	}
+	if sc.Files != "" && !matchFileGlob(sc.Files, filename, patternBase(filename)) {
+		return false
+	}
+	return true
+}
+
+// packagePatternRegexp translates a package path pattern into an anchored
+// regular expression.
+func packagePatternRegexp(pattern string) *regexp.Regexp {
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\.\.\.`, `.*`)
+	if e, ok := strings.CutSuffix(expr, `/.*`); ok {
+		expr = e + `(?:/.*)?`
+	}
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// matchFileGlob reports whether the file name matches the glob pattern, in
+// which "**" matches any number of path elements. A pattern that starts with
+// "/" is matched against the absolute name, and others against the name
+// relative to the directory base, so that they never match files outside it.
+func matchFileGlob(pattern, name, base string) bool {
+	if strings.HasPrefix(pattern, "/") {
+		name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+		return matchElems(strings.Split(pattern[1:], "/"), strings.Split(name, "/"))
+	}
+	rel, err := filepath.Rel(base, name)
+	if err != nil || !filepath.IsLocal(rel) {
+		return false
+	}
+	return matchElems(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(rel), "/"))
+}
+
+// patternBase returns the directory that relative glob patterns are matched
+// from for the file name: the root of its module, where its go.mod file is,
+// or the root of the file system if it is not in a module.
+func patternBase(name string) string {
+	for dir := filepath.Dir(name); ; {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return filepath.VolumeName(name) + string(filepath.Separator)
+}
+
+// anchorGlob returns the glob pattern, if it is relative, as an absolute
+// pattern relative to the directory dir.
+func anchorGlob(pattern, dir string) string {
+	if pattern == "" || strings.HasPrefix(pattern, "/") {
+		return pattern
+	}
+	var b strings.Builder
+	dir = filepath.ToSlash(dir)
+	if !strings.HasPrefix(dir, "/") {
+		b.WriteByte('/')
+	}
+	for _, r := range strings.TrimSuffix(dir, "/") {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('/')
+	b.WriteString(pattern)
+	return b.String()
+}
+
+func matchElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
@@ -0,0 +1,152 @@
+package errcheck
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchFileGlob(t *testing.T) {
+	// The directory of the checkout contains the patterns, which must not
+	// match it.
+	const base = "/ci/examples/third_party/m"
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"examples/**", base + "/examples/a.go", true},
+		{"examples/**", base + "/examples/x/y/a.go", true},
+		{"examples/**", base + "/example/a.go", false},
+		{"examples/**", base + "/a.go", false},
+		{"examples/**", base + "/x/examples/a.go", false},
+		{"examples/**", "/ci/examples/a.go", false},
+		{"third_party/**", base + "/a.go", false},
+		{"examples/*.go", base + "/examples/a.go", true},
+		{"examples/*.go", base + "/examples/x/a.go", false},
+		{"cmd/**/main.go", base + "/cmd/tool/main.go", true},
+		{"cmd/**/main.go", base + "/cmd/main.go", true},
+		{"cmd/**/main.go", base + "/cmd/tool/other.go", false},
+		{"*_test.go", base + "/a_test.go", true},
+		{"*_test.go", base + "/x/a_test.go", false},
+		{"**/*_test.go", base + "/x/a_test.go", true},
+		{"/ci/examples/third_party/m/*.go", base + "/a.go", true},
+		{"/m/*.go", base + "/a.go", false},
+	}
+
+	for _, c := range cases {
+		if got := matchFileGlob(c.pattern, c.name, base); got != c.match {
+			t.Errorf("matchFileGlob(%q, %q) = %v, want %v", c.pattern, c.name, got, c.match)
+		}
+	}
+}
+
+func TestAnchorGlob(t *testing.T) {
+	cases := []struct {
+		pattern, dir, want string
+	}{
+		{"examples/**", "/src/m", "/src/m/examples/**"},
+		{"/abs/*.go", "/src/m", "/abs/*.go"},
+		{"*.go", "/src/[m]*", `/src/\[m]\*/*.go`},
+	}
+	for _, c := range cases {
+		if got := anchorGlob(c.pattern, c.dir); got != c.want {
+			t.Errorf("anchorGlob(%q, %q) = %q, want %q", c.pattern, c.dir, got, c.want)
+		}
+	}
+	if !matchFileGlob(`/src/\[m]\*/*.go`, "/src/[m]*/x.go", "/") {
+		t.Errorf("an escaped directory does not match itself")
+	}
+}
+
+func TestPackagePatternRegexp(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"example.com/m/cmd/tools/...", "example.com/m/cmd/tools", true},
+		{"example.com/m/cmd/tools/...", "example.com/m/cmd/tools/gen", true},
+		{"example.com/m/cmd/tools/...", "example.com/m/cmd/toolsx", false},
+		{"example.com/m/.../internal", "example.com/m/a/b/internal", true},
+		{"example.com/m", "example.com/m/a", false},
+		{"example.com/m", "example.com/m", true},
+	}
+
+	for _, c := range cases {
+		if got := packagePatternRegexp(c.pattern).MatchString(c.path); got != c.match {
+			t.Errorf("pattern %q on %q = %v, want %v", c.pattern, c.path, got, c.match)
+		}
+	}
+}
+
+func TestScopedExclusions(t *testing.T) {
+	// The module is checked out below a directory named examples, which the
+	// file globs are not matched against.
+	root := filepath.Join(t.TempDir(), "examples", "m")
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+	yes, no := true, false
+	e := Exclusions{
+		BlankAssignments: true,
+		Symbols:          []string{"fmt.Println"},
+		Scopes: []Scope{
+			{Packages: "example.com/m/cmd/...", Symbols: []string{"(*os.File).Close"}, BlankAssignments: &no},
+			{Name: "examples", Files: "**/examples/**", BlankAssignments: &yes},
+			{Packages: "example.com/m/...", Files: "**/*_test.go", TypeAssertions: &yes},
+		},
+	}
+	s := newScopedExclusions(&e)
+
+	cases := []struct {
+		pkgPath, filename string
+		blank, asserts    bool
+		closeExcluded     bool
+		scope             string
+	}{
+		{"example.com/m/lib", "lib/a.go", false, true, false, ""},
+		{"example.com/m/cmd/tool", "cmd/tool/a.go", true, true, true, "example.com/m/cmd/..."},
+		// Later scopes override earlier ones.
+		{"example.com/m/cmd/tool", "cmd/tool/examples/a.go", false, true, true, "example.com/m/cmd/..., examples"},
+		{"example.com/m/lib_test", "lib/a_test.go", false, false, false, "example.com/m/... **/*_test.go"},
+	}
+
+	for _, c := range cases {
+		var v visitor
+		s.configure(&v, c.pkgPath, filepath.Join(root, filepath.FromSlash(c.filename)))
+		if v.blank != c.blank || v.asserts != c.asserts || v.scope != c.scope {
+			t.Errorf("%s: got blank %v asserts %v scope %q, want %v %v %q",
+				c.filename, v.blank, v.asserts, v.scope, c.blank, c.asserts, c.scope)
+		}
+		if got := len(v.exclude.match("(*os.File).Close")) > 0; got != c.closeExcluded {
+			t.Errorf("%s: (*os.File).Close excluded = %v, want %v", c.filename, got, c.closeExcluded)
+		}
+		if len(v.exclude.match("fmt.Println")) == 0 {
+			t.Errorf("%s: expected fmt.Println to be excluded", c.filename)
+		}
+	}
+}
+
+func TestExcludedPath(t *testing.T) {
+	// The module is checked out below directories named like the patterns.
+	root := filepath.Join(t.TempDir(), "third_party", "examples", "m")
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+	e := Exclusions{Paths: []string{"third_party/", "examples/**", "**/*_mock.go", "/abs/gen/*.go"}}
+	cases := map[string]bool{
+		"third_party/x/y.go":  true,
+		"third_party.go":      false,
+		"examples/a/main.go":  true,
+		"store_mock.go":       true,
+		"internal/db_mock.go": true,
+		"mock/store.go":       false,
+		"internal/handler.go": false,
+		"x/examples/a.go":     false,
+	}
+	for name, want := range cases {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if got := e.excludedPath(name); got != want {
+			t.Errorf("excludedPath(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !e.excludedPath("/abs/gen/x.go") || e.excludedPath("/src/abs/gen/x.go") {
+		t.Errorf("absolute patterns are not matched against the absolute path")
+	}
+}
@@ -0,0 +1,376 @@
+package errcheck
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// symbolRule is a parsed entry of Exclusions.Symbols.
+//
+// An entry names a function or method and may constrain the arguments of
+// the calls it excludes:
+//
+//	io.Copy(io.Discard, *)
+//	fmt.Fprintf(net/http.ResponseWriter)
+//	strconv.ParseInt(*, 10, 64)
+//
+// Each argument pattern is "*" (or "_") for any argument, the static type of
+// the argument, the qualified name of the package variable or constant that
+// the argument refers to, or the exact constant value of the argument.
+// Arguments beyond those listed are not constrained.
+//
+// An entry of the form "pkg.F(ARGS).Method(ARGS)" excludes calls of Method
+// on the result of a call of pkg.F, as in
+//
+//	encoding/json.NewEncoder(net/http.ResponseWriter).Encode
+//
+// which matches json.NewEncoder(w).Encode(v) for a http.ResponseWriter w.
+// The method must be called on the result directly.
+//
+// An entry that starts with "!" is negated. Calls that match a negated
+// entry are never excluded.
+type symbolRule struct {
+	entry string
+
+	// negated is set for entries that start with "!", which prevent the
+	// calls they match from being excluded.
+	negated bool
+
+	// name is the function or method name, possibly with wildcards.
+	name string
+	re   *regexp.Regexp
+
+	// args holds the argument patterns, or nil if there are none.
+	args []string
+
+	// method and methodArgs are set for entries that exclude a method
+	// called on the result of the named function.
+	method     string
+	methodArgs []string
+}
+
+// parseSymbol parses an entry of Exclusions.Symbols.
+func parseSymbol(entry string) (*symbolRule, error) {
+	r := &symbolRule{entry: entry}
+	if rest, ok := strings.CutPrefix(entry, "!"); ok {
+		r.negated = true
+		entry = rest
+	}
+
+	// The receiver of a method is part of its name.
+	i := 0
+	if strings.HasPrefix(entry, "(") {
+		end := closingParen(entry)
+		if end < 0 {
+			return nil, errors.New("unbalanced parentheses")
+		}
+		i = end + 1
+	}
+	if j := strings.IndexByte(entry[i:], '('); j >= 0 {
+		i += j
+	} else {
+		i = len(entry)
+	}
+	r.name = entry[:i]
+	if r.name == "" {
+		return nil, errors.New("missing function name")
+	}
+	if hasSpace(r.name) {
+		return nil, errors.New("unexpected space in function name")
+	}
+	if strings.Contains(strings.ReplaceAll(r.name, AnyMajor, ""), "@") {
+		return nil, fmt.Errorf("unexpected @ in function name; only %s is supported", AnyMajor)
+	}
+
+	rest := entry[i:]
+	if rest == "" {
+		return r, nil
+	}
+	args, rest, err := parseArgPatterns(rest)
+	if err != nil {
+		return nil, err
+	}
+	r.args = args
+	if rest == "" {
+		return r, nil
+	}
+
+	method, ok := strings.CutPrefix(rest, ".")
+	if !ok {
+		return nil, fmt.Errorf("unexpected %q after argument list", rest)
+	}
+	if j := strings.IndexByte(method, '('); j >= 0 {
+		method, rest = method[:j], method[j:]
+		if r.methodArgs, rest, err = parseArgPatterns(rest); err != nil {
+			return nil, err
+		}
+		if rest != "" {
+			return nil, fmt.Errorf("unexpected %q after argument list", rest)
+		}
+	}
+	if method == "" {
+		return nil, errors.New("missing method name")
+	}
+	if hasSpace(method) {
+		return nil, errors.New("unexpected space in method name")
+	}
+	r.method = method
+	return r, nil
+}
+
+// closingParen returns the index of the parenthesis that closes the one
+// that s starts with, or -1 if there is none.
+func closingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// hasSpace reports whether s contains white space outside of brackets, as
+// in type argument lists.
+func hasSpace(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ' ', '\t':
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseArgPatterns parses the parenthesized argument list that s starts
+// with and returns the remainder of s.
+func parseArgPatterns(s string) (args []string, rest string, err error) {
+	end := closingParen(s)
+	if end < 0 {
+		return nil, "", errors.New("unbalanced parentheses")
+	}
+	list, rest := s[1:end], s[end+1:]
+
+	args = []string{}
+	if strings.TrimSpace(list) == "" {
+		return args, rest, nil
+	}
+	depth, start := 0, 0
+	for i := 0; i <= len(list); i++ {
+		if i < len(list) {
+			switch list[i] {
+			case '(', '[', '{':
+				depth++
+				continue
+			case ')', ']', '}':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		arg := strings.TrimSpace(list[start:i])
+		if arg == "" {
+			return nil, "", errors.New("empty argument pattern")
+		}
+		args = append(args, arg)
+		start = i + 1
+	}
+	return args, rest, nil
+}
+
+// AnyMajor follows a module path in an exclusion entry to match every major
+// version of the module, as in "github.com/foo/bar@any-major.New", which
+// matches github.com/foo/bar.New as well as github.com/foo/bar/v2.New and
+// github.com/foo/bar/v3.New. The module path is written without a major
+// version suffix. It also matches the gopkg.in form, as in
+// "gopkg.in/yaml@any-major.Marshal" for gopkg.in/yaml.v3.Marshal.
+const AnyMajor = "@any-major"
+
+// majorVersionExpr is the regular expression that AnyMajor stands for.
+const majorVersionExpr = `(?:[/.]v[0-9]+)?`
+
+// anyMajorRegexps caches the regular expressions of argument patterns that
+// contain AnyMajor.
+var anyMajorRegexps sync.Map
+
+// anyMajorRegexp returns an anchored regular expression for an argument
+// pattern that contains AnyMajor, or nil if the pattern does not contain it
+// and is matched literally.
+func anyMajorRegexp(pattern string) *regexp.Regexp {
+	if !strings.Contains(pattern, AnyMajor) {
+		return nil
+	}
+	if re, ok := anyMajorRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), regexp.QuoteMeta(AnyMajor), majorVersionExpr)
+	re, _ := anyMajorRegexps.LoadOrStore(pattern, regexp.MustCompile("^"+expr+"$"))
+	return re.(*regexp.Regexp)
+}
+
+// symbolMatcher matches the names of called functions against the entries of
+// Exclusions.Symbols.
+//
+// Entries without wildcards are looked up in a map. Entries with wildcards
+// are compiled into a single regular expression that is only consulted for
+// names that have not been seen before; the entries that matched are then
+// found and the result cached.
+type symbolMatcher struct {
+	exact map[string][]*symbolRule
+	globs []*symbolRule
+
+	// any matches the names that match any of globs.
+	any *regexp.Regexp
+
+	// cache maps names to the glob entries that match them. It is read
+	// far more often than written, by the visitors of all packages.
+	cache sync.Map // of string to []*symbolRule
+}
+
+// newSymbolMatcher returns a matcher for the exclusion entries in symbols.
+// Entries that cannot be parsed never match.
+//
+// In the name of an entry, "*" matches any sequence of characters other than
+// '.', '/', '(' and ')', so it stays within a single package path element,
+// type or function name. A '*' that starts a type, following '(' or ',',
+// denotes a pointer as usual. "..." matches any sequence of characters, and
+// an entry consisting of a package path followed by "/..." matches every
+// function and method of that package and the packages below it. For
+// example:
+//
+//	(*bytes.Buffer).*           // every method of *bytes.Buffer
+//	(*example.com/log.*).Write* // Write methods of pointers to log types
+//	example.com/metrics/...     // everything in metrics and its subpackages
+//
+// AnyMajor matches an optional major version suffix.
+func newSymbolMatcher(symbols []string) *symbolMatcher {
+	m := &symbolMatcher{
+		exact: make(map[string][]*symbolRule),
+	}
+	var exprs []string
+	for _, sym := range symbols {
+		r, err := parseSymbol(sym)
+		if err != nil {
+			continue
+		}
+		if !isSymbolGlob(r.name) {
+			m.exact[r.name] = append(m.exact[r.name], r)
+			continue
+		}
+		expr := symbolGlobExpr(r.name)
+		r.re = regexp.MustCompile(expr)
+		m.globs = append(m.globs, r)
+		exprs = append(exprs, "(?:"+expr+")")
+	}
+	if len(exprs) > 0 {
+		m.any = regexp.MustCompile(strings.Join(exprs, "|"))
+	}
+	return m
+}
+
+// match returns the entries whose name matches name.
+func (m *symbolMatcher) match(name string) []*symbolRule {
+	rules := m.exact[name]
+	if m.any == nil {
+		return rules
+	}
+	var globs []*symbolRule
+	if v, ok := m.cache.Load(name); ok {
+		globs = v.([]*symbolRule)
+	} else {
+		if m.any.MatchString(name) {
+			for _, r := range m.globs {
+				if r.re.MatchString(name) {
+					globs = append(globs, r)
+				}
+			}
+		}
+		m.cache.Store(name, globs)
+	}
+	if len(rules) == 0 {
+		return globs
+	}
+	return append(rules[:len(rules):len(rules)], globs...)
+}
+
+// isSymbolGlob reports whether the name of an exclusion entry contains
+// wildcards.
+func isSymbolGlob(sym string) bool {
+	if strings.Contains(sym, "...") || strings.Contains(sym, AnyMajor) {
+		return true
+	}
+	for i := range len(sym) {
+		if sym[i] == '*' && !isPointerStar(sym, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPointerStar reports whether the '*' at sym[i] denotes a pointer type
+// rather than a wildcard.
+func isPointerStar(sym string, i int) bool {
+	if i > 0 && sym[i-1] != '(' && sym[i-1] != ',' && sym[i-1] != ' ' {
+		return false
+	}
+	if i+1 >= len(sym) {
+		return false
+	}
+	c := sym[i+1]
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// symbolGlobExpr translates an exclusion entry with wildcards into an
+// anchored regular expression.
+func symbolGlobExpr(sym string) string {
+	if pkg, ok := strings.CutSuffix(sym, "/..."); ok && !strings.ContainsAny(pkg, "()") {
+		path := globExpr(pkg) + `(?:/[^()]*)?`
+		name := `\.[^/()]*`
+		return `^(?:` + path + name + `|\(\*?` + path + name + `\)` + name + `)$`
+	}
+	return "^" + globExpr(sym) + "$"
+}
+
+// globExpr translates the wildcards of sym into regular expression syntax
+// and quotes everything else.
+func globExpr(sym string) string {
+	var b strings.Builder
+	for i := 0; i < len(sym); {
+		switch {
+		case strings.HasPrefix(sym[i:], "..."):
+			b.WriteString(".*")
+			i += 3
+		case strings.HasPrefix(sym[i:], AnyMajor):
+			b.WriteString(majorVersionExpr)
+			i += len(AnyMajor)
+		case sym[i] == '*' && !isPointerStar(sym, i):
+			b.WriteString(`[^./()]*`)
+			i++
+		default:
+			b.WriteString(regexp.QuoteMeta(sym[i : i+1]))
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package errcheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	cases := []struct {
+		entry      string
+		name       string
+		args       []string
+		method     string
+		methodArgs []string
+		err        bool
+	}{
+		{entry: "fmt.Println", name: "fmt.Println"},
+		{entry: "(*bytes.Buffer).Write", name: "(*bytes.Buffer).Write"},
+		{entry: "fmt.Fprintf(os.Stderr)", name: "fmt.Fprintf", args: []string{"os.Stderr"}},
+		{entry: "io.Copy(io.Discard, *)", name: "io.Copy", args: []string{"io.Discard", "*"}},
+		{entry: "strconv.ParseInt(*, 10, 64)", name: "strconv.ParseInt", args: []string{"*", "10", "64"}},
+		{entry: "f(map[string]int, func(int, int))", name: "f", args: []string{"map[string]int", "func(int, int)"}},
+		{entry: "(*encoding/json.Encoder).Encode(*)", name: "(*encoding/json.Encoder).Encode", args: []string{"*"}},
+		{entry: "hello()", name: "hello", args: []string{}},
+		{
+			entry:  "encoding/json.NewEncoder(net/http.ResponseWriter).Encode",
+			name:   "encoding/json.NewEncoder",
+			args:   []string{"net/http.ResponseWriter"},
+			method: "Encode",
+		},
+		{
+			entry:      "example.com/db.Open(*).Exec(\"VACUUM\")",
+			name:       "example.com/db.Open",
+			args:       []string{"*"},
+			method:     "Exec",
+			methodArgs: []string{`"VACUUM"`},
+		},
+		{entry: "!crypto/rand.Read", name: "crypto/rand.Read"},
+		{entry: "!fmt.Fprintf(os.Stderr)", name: "fmt.Fprintf", args: []string{"os.Stderr"}},
+		{entry: "!", err: true},
+		{entry: "example.com/foo@any-major.New", name: "example.com/foo@any-major.New"},
+		{entry: "example.com/foo@v2.New", err: true},
+		{entry: "fmt.Fprintf(os.Stderr", err: true},
+		{entry: "(*bytes.Buffer.Write", err: true},
+		{entry: "io.Copy(io.Discard,, *)", err: true},
+		{entry: "f(x)y", err: true},
+		{entry: "f(x).", err: true},
+		{entry: "(x)", name: "(x)"},
+		{entry: "(*example.com/m.Map[K, V]).Set", name: "(*example.com/m.Map[K, V]).Set"},
+		{entry: "(int)", name: "(int)"},
+		{entry: "", err: true},
+		{entry: "fmt.Println please", err: true},
+		{entry: "includes other.txt", err: true},
+		{entry: "f(x).Close it", err: true},
+	}
+
+	for _, c := range cases {
+		r, err := parseSymbol(c.entry)
+		if c.err {
+			if err == nil {
+				t.Errorf("parseSymbol(%q): expected an error", c.entry)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSymbol(%q): %v", c.entry, err)
+			continue
+		}
+		if r.name != c.name || !reflect.DeepEqual(r.args, c.args) || r.method != c.method || !reflect.DeepEqual(r.methodArgs, c.methodArgs) {
+			t.Errorf("parseSymbol(%q) = %q %q %q %q, want %q %q %q %q", c.entry,
+				r.name, r.args, r.method, r.methodArgs, c.name, c.args, c.method, c.methodArgs)
+		}
+	}
+}
+
+func TestSymbolMatcher(t *testing.T) {
+	m := newSymbolMatcher([]string{
+		"fmt.Println",
+		"(*bytes.Buffer).*",
+		"(*example.com/log.*).Write*",
+		"example.com/metrics/...",
+		"fmt.Fprint*(os.Stderr)",
+		"(...).Close",
+		"example.com/foo@any-major.New",
+		"(*example.com/foo@any-major.Client).Shutdown",
+		"gopkg.in/yaml@any-major.Marshal",
+	})
+
+	cases := []struct {
+		name  string
+		entry string
+	}{
+		{"fmt.Println", "fmt.Println"},
+		{"fmt.Printf", ""},
+		{"(*bytes.Buffer).WriteString", "(*bytes.Buffer).*"},
+		{"(bytes.Buffer).String", ""},
+		{"(*bytes.Reader).Read", ""},
+		{"(*example.com/log.Logger).Write", "(*example.com/log.*).Write*"},
+		{"(*example.com/log.Logger).WriteString", "(*example.com/log.*).Write*"},
+		{"(example.com/log.Logger).Write", ""},
+		{"(*example.com/log.Logger).Read", ""},
+		{"(*example.com/log/sub.Logger).Write", ""},
+		{"example.com/metrics.Inc", "example.com/metrics/..."},
+		{"example.com/metrics/sub.v2/x.Inc", "example.com/metrics/..."},
+		{"(*example.com/metrics/sub.Counter).Inc", "example.com/metrics/..."},
+		{"(example.com/metrics.Gauge).Set", "example.com/metrics/..."},
+		{"example.com/metricsx.Inc", ""},
+		{"example.com/other.Inc", ""},
+		{"fmt.Fprintln", "fmt.Fprint*(os.Stderr)"},
+		{"(*os.File).Close", "(...).Close"},
+		{"(io.Closer).Close", "(...).Close"},
+		{"os.Close", ""},
+		{"example.com/foo.New", "example.com/foo@any-major.New"},
+		{"example.com/foo/v2.New", "example.com/foo@any-major.New"},
+		{"example.com/foo/v13.New", "example.com/foo@any-major.New"},
+		{"example.com/foobar.New", ""},
+		{"example.com/foo/v2/sub.New", ""},
+		{"(*example.com/foo/v4.Client).Shutdown", "(*example.com/foo@any-major.Client).Shutdown"},
+		{"(example.com/foo/v4.Client).Shutdown", ""},
+		{"gopkg.in/yaml.v3.Marshal", "gopkg.in/yaml@any-major.Marshal"},
+	}
+
+	for _, c := range cases {
+		// Match twice to exercise the cache.
+		for range 2 {
+			var entry string
+			if rules := m.match(c.name); len(rules) > 0 {
+				entry = rules[0].entry
+			}
+			if entry != c.entry {
+				t.Errorf("match(%q) = %q; want %q", c.name, entry, c.entry)
+			}
+		}
+	}
+}
+
+func TestIsSymbolGlob(t *testing.T) {
+	cases := []struct {
+		sym  string
+		glob bool
+	}{
+		{"fmt.Println", false},
+		{"(*bytes.Buffer).Write", false},
+		{"fmt.Fprintf(*bytes.Buffer)", false},
+		{"(*bytes.Buffer).*", true},
+		{"fmt.Fprint*", true},
+		{"example.com/metrics/...", true},
+		{"(*example.com/*.Client).Do", true},
+		{"example.com/foo@any-major.New", true},
+	}
+
+	for _, c := range cases {
+		if got := isSymbolGlob(c.sym); got != c.glob {
+			t.Errorf("isSymbolGlob(%q) = %v, want %v", c.sym, got, c.glob)
+		}
+	}
+}
+
+func TestWithNonVendoredNames(t *testing.T) {
+	names := withNonVendoredNames([]string{
+		"example.com/m/vendor/example.net/fmt2.Println",
+		"(*example.com/m/vendor/example.net/log.Logger).Write",
+		"map[string]*a/vendor/b/vendor/c.T",
+		"fmt.Println",
+	})
+	want := []string{
+		"example.com/m/vendor/example.net/fmt2.Println",
+		"(*example.com/m/vendor/example.net/log.Logger).Write",
+		"map[string]*a/vendor/b/vendor/c.T",
+		"fmt.Println",
+		"example.net/fmt2.Println",
+		"(*example.net/log.Logger).Write",
+		"map[string]*c.T",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+}
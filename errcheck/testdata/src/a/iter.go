@@ -0,0 +1,19 @@
+package a
+
+import "iter"
+
+func rows() iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {}
+}
+
+func rangeOverIterators() {
+	for r := range rows() { // want "unchecked error"
+		_ = r
+	}
+	for r, _ := range rows() { // ok, assigned to blank
+		_ = r
+	}
+	for r, err := range rows() {
+		_, _ = r, err
+	}
+}
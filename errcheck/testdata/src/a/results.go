@@ -0,0 +1,23 @@
+package a
+
+type aliasError = error
+
+func aliasErr() aliasError {
+	return nil
+}
+
+func temporaryErr() interface {
+	error
+	Temporary() bool
+} {
+	return nil
+}
+
+func genericErr[E error](f func() E) {
+	f() // want "unchecked error"
+}
+
+func errorResults() {
+	aliasErr()     // want "unchecked error"
+	temporaryErr() // want "unchecked error"
+}
@@ -1,6 +1,9 @@
 package blank
 
-import "fmt"
+import (
+	"fmt"
+	"iter"
+)
 
 func a() error {
 	return nil
@@ -35,3 +38,13 @@ func main() {
 		fmt.Printf("r = %v\n", r)
 	}
 }
+
+func rows() iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {}
+}
+
+func rangeOverIterator() {
+	for r, _ := range rows() { // want "unchecked error"
+		_ = r
+	}
+}
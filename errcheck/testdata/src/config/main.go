@@ -0,0 +1,13 @@
+package config
+
+type store struct{}
+
+func (store) Flush() error { return nil }
+func (store) Close() error { return nil }
+
+func main() {
+	var s store
+	s.Flush()     // excluded by the configuration file
+	s.Close()     // want "unchecked error"
+	_ = s.Close() // want "unchecked error"
+}
@@ -0,0 +1,100 @@
+package deferclose
+
+import (
+	"io"
+	"os"
+)
+
+func named(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // want "unchecked error"
+	_, err = io.WriteString(f, "hello")
+	return err
+}
+
+func unnamed(w io.WriteCloser) (int, error) {
+	defer w.Close() // want "unchecked error"
+	return io.WriteString(w, "hello")
+}
+
+func single(w io.WriteCloser) error {
+	defer w.Close() // want "unchecked error"
+	_, err := io.WriteString(w, "hello")
+	return err
+}
+
+func blank(w io.WriteCloser) (n int, _ error) {
+	defer w.Close() // want "unchecked error"
+	return 0, nil
+}
+
+func closure() {
+	_ = func(w io.WriteCloser) error {
+		defer w.Close() // want "unchecked error"
+		return nil
+	}
+}
+
+// No safe rewrite exists for the following calls.
+
+func noError(w io.WriteCloser) {
+	defer w.Close() // want "unchecked error"
+}
+
+func reassigned(a, b io.WriteCloser) error {
+	defer a.Close() // want "unchecked error"
+	a = b
+	return nil
+}
+
+type holder struct {
+	w io.WriteCloser
+}
+
+func fieldReassigned(h holder, b io.WriteCloser) error {
+	defer h.w.Close() // want "unchecked error"
+	h.w = b
+	return nil
+}
+
+type counter struct {
+	n int
+}
+
+func (c counter) Close() error { return nil }
+
+func (c *counter) Inc() { c.n++ }
+
+func pointerMethod() error {
+	var c counter
+	defer c.Close() // want "unchecked error"
+	c.Inc()
+	return nil
+}
+
+func copiedReceiver(c *counter) error {
+	defer c.Close() // want "unchecked error"
+	return nil
+}
+
+func evaluated(ws []io.WriteCloser) error {
+	defer ws[0].Close() // want "unchecked error"
+	return nil
+}
+
+func shadowed(w io.WriteCloser) (err error) {
+	{
+		err := 1
+		defer w.Close() // want "unchecked error"
+		_ = err
+	}
+	return nil
+}
+
+func multiValue(w io.Writer) error {
+	defer w.Write(nil) // want "unchecked error"
+	return nil
+}
@@ -0,0 +1,20 @@
+package directives
+
+func a() error {
+	return nil
+}
+
+func main() {
+	a() //errcheck:ignore the result does not matter here
+
+	//errcheck:ignore a whole statement can be ignored
+	for i := 0; i < 3; i++ {
+		a()
+	}
+
+	/* want "errcheck:ignore directive must give a reason" */ //errcheck:ignore
+	a() // want "unchecked error"
+
+	a() //nolint:errcheck
+	a() //lint:ignore errcheck the result does not matter here
+}
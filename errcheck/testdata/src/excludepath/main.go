@@ -0,0 +1,7 @@
+package excludepath
+
+func f() error { return nil }
+
+func main() {
+	f() // want "unchecked error"
+}
@@ -0,0 +1,5 @@
+package excludepath
+
+func mock() {
+	f() // excluded by path
+}
@@ -0,0 +1,96 @@
+package fix
+
+import (
+	"bytes"
+	"errors"
+)
+
+type T struct{ n int }
+
+type MyErr struct{}
+
+func (*MyErr) Error() string { return "" }
+
+func mayFail() error { return nil }
+
+func pair() (int, error) { return 0, nil }
+
+func twoErrors() (error, error) { return nil, nil }
+
+func concrete() *MyErr { return nil }
+
+func single() error {
+	mayFail() // want "unchecked error"
+	return nil
+}
+
+func results() (int, string, bool, []byte, error) {
+	mayFail() // want "unchecked error"
+	return 0, "", false, nil, nil
+}
+
+func multiValue() (*T, error) {
+	pair() // want "unchecked error"
+	return nil, nil
+}
+
+func shadowed(err error) error {
+	mayFail() // want "unchecked error"
+	return err
+}
+
+func structs() (T, bytes.Buffer, [2]int, error) {
+	mayFail() // want "unchecked error"
+	return T{}, bytes.Buffer{}, [2]int{}, nil
+}
+
+func generic[E any]() (E, error) {
+	mayFail() // want "unchecked error"
+	var e E
+	return e, nil
+}
+
+func closure() {
+	_ = func() error {
+		if true {
+			mayFail() // want "unchecked error"
+		}
+		return nil
+	}
+}
+
+// No safe rewrite exists for the following calls.
+
+func noError() {
+	mayFail() // want "unchecked error"
+}
+
+func goroutine() error {
+	go mayFail() // want "unchecked error"
+	return nil
+}
+
+func ambiguous() error {
+	twoErrors() // want "unchecked error"
+	concrete()  // want "unchecked error"
+	return nil
+}
+
+func shadowedType() (T, error) {
+	T := 1
+	mayFail() // want "unchecked error"
+	return struct{ n int }{T}, nil
+}
+
+func headers() error {
+	for i := 0; i < 3; mayFail() { // want "unchecked error"
+		i++
+	}
+	if mayFail(); true { // want "unchecked error"
+	}
+	switch mayFail(); { // want "unchecked error"
+	}
+	return nil
+}
+
+var _ = errors.New
@@ -0,0 +1,8 @@
+// Code generated by protoc-gen-gogo.
+// source: service.proto
+
+package generated
+
+func gogo() {
+	f() // excluded by the marker
+}
@@ -0,0 +1,15 @@
+package negated
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Fprintf(os.Stderr, "hello\n") // want "unchecked error"
+	fmt.Fprintln(os.Stderr, "hello")  // still excluded by default
+
+	b := make([]byte, 16)
+	rand.Read(b) // want "unchecked error"
+}
@@ -0,0 +1,13 @@
+package receive
+
+func receive(errc chan error, done chan struct{}) {
+	<-errc     // want "error received from channel is discarded"
+	_ = <-errc // want "error received from channel is discarded"
+
+	select {
+	case <-errc: // want "error received from channel is discarded"
+	case err := <-errc:
+		_ = err
+	case <-done:
+	}
+}
@@ -0,0 +1,46 @@
+package shadow
+
+func a() error {
+	return nil
+}
+
+func b() (int, error) {
+	return 0, nil
+}
+
+func shadowed() error {
+	err := a()
+	if err := a(); err != nil { // want "declaration shadows an error that has not been checked"
+		return err
+	}
+	err = a()
+	return err
+}
+
+func shadowedInBlock(cond bool) (int, error) {
+	n, err := b()
+	if cond {
+		n, err := b() // want "declaration shadows an error that has not been checked"
+		return n, err
+	}
+	return n, err
+}
+
+func readLater() error {
+	err := a()
+	if err := a(); err != nil {
+		return err
+	}
+	return err
+}
+
+func checked() error {
+	err := a()
+	if err != nil {
+		return err
+	}
+	if err := a(); err != nil {
+		return err
+	}
+	return nil
+}
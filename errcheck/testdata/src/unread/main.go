@@ -0,0 +1,34 @@
+package unread
+
+func a() error {
+	return nil
+}
+
+func overwritten() error {
+	err := a() // want "assigned value is overwritten or never read"
+	err = a()
+	return err
+}
+
+func namedResult() (err error) {
+	err = a() // want "assigned value is overwritten or never read"
+	return nil
+}
+
+func checked() error {
+	err := a()
+	if err != nil {
+		return err
+	}
+	err = a()
+	return err
+}
+
+func literal() {
+	f := func() error {
+		err := a() // want "assigned value is overwritten or never read"
+		err = a()
+		return err
+	}
+	_ = f
+}
@@ -0,0 +1,533 @@
+package errcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SymbolProblem describes an entry of Exclusions.Symbols that does not refer
+// to an existing function, method or type, and so excludes nothing.
+type SymbolProblem struct {
+	// Symbol is the entry as given.
+	Symbol string
+
+	// Message describes the problem.
+	Message string
+
+	// Suggestion is the closest correct spelling of the entry, or empty if
+	// there is none.
+	Suggestion string
+}
+
+func (p SymbolProblem) String() string {
+	if p.Suggestion != "" {
+		return fmt.Sprintf("%s: %s (did you mean %s?)", p.Symbol, p.Message, p.Suggestion)
+	}
+	return fmt.Sprintf("%s: %s", p.Symbol, p.Message)
+}
+
+// ValidateSymbols resolves entries of Exclusions.Symbols against the type
+// information of pkgs and the packages they import, and returns the problems
+// found. Since pkgs hold only part of the packages they import, the packages
+// that the entries name are loaded on their own, with the build tags and
+// module mode of c. It reports unknown packages, functions, types and
+// methods, methods named with the wrong pointer or value receiver, and
+// argument patterns that are not types, package variables or constants.
+//
+// Only the package path of entries with wildcards or AnyMajor is checked.
+// Entries that cannot be parsed are reported as well. An error is returned if
+// the packages that the entries name cannot be loaded.
+func (c *Checker) ValidateSymbols(symbols []string, pkgs []*packages.Package) ([]SymbolProblem, error) {
+	vs := &symbolValidator{pkgs: map[string]*types.Package{}, complete: map[string]bool{}}
+	for _, pkg := range pkgs {
+		if pkg.Types != nil {
+			vs.complete[nonVendoredPkgPath(pkg.Types.Path())] = true
+			vs.addPackage(pkg.Types)
+		}
+	}
+	if err := vs.loadPackages(symbols, c.buildFlags()); err != nil {
+		return nil, err
+	}
+
+	var problems []SymbolProblem
+	for _, sym := range symbols {
+		if msg, suggestion := vs.validate(sym); msg != "" {
+			problems = append(problems, SymbolProblem{Symbol: sym, Message: msg, Suggestion: suggestion})
+		}
+	}
+	return problems, nil
+}
+
+// symbolValidator resolves the names in exclusion entries.
+type symbolValidator struct {
+	// pkgs maps the paths of all known packages to their types.
+	pkgs map[string]*types.Package
+
+	// paths lists the keys of pkgs, in order.
+	paths []string
+
+	// complete holds the paths of the packages whose scope is complete. The
+	// other packages were imported from export data, which may leave out the
+	// objects that their importers do not use.
+	complete map[string]bool
+}
+
+// loadPackages loads the packages named in symbols whose scope is not
+// complete, with the given build flags. Packages that do not exist are left
+// out, so that the entries naming them are reported as unknown.
+func (vs *symbolValidator) loadPackages(symbols []string, buildFlags []string) error {
+	var paths []string
+	need := func(path string) {
+		if path != "" && !vs.complete[path] && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	for _, sym := range symbols {
+		r, err := parseSymbol(sym)
+		if err != nil {
+			continue
+		}
+		pkgPath, _, _, ok := splitSymbolName(r.name)
+		if p, found := strings.CutSuffix(r.name, "/..."); found {
+			pkgPath, ok = p, true
+		}
+		if ok && !strings.Contains(pkgPath, "*") && !strings.Contains(pkgPath, "...") && anyMajorRegexp(pkgPath) == nil {
+			need(pkgPath)
+		}
+		for _, arg := range append(r.args[:len(r.args):len(r.args)], r.methodArgs...) {
+			for _, m := range qualifiedIdent.FindAllStringSubmatch(arg, -1) {
+				need(m[1])
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedTypes,
+		BuildFlags: buildFlags,
+	}
+	loaded, err := packages.Load(cfg, paths...)
+	if err != nil {
+		return fmt.Errorf("loading the packages of exclude entries: %w", err)
+	}
+	for _, pkg := range loaded {
+		if len(pkg.Errors) > 0 || pkg.Types == nil {
+			continue
+		}
+		path := nonVendoredPkgPath(pkg.Types.Path())
+		if _, ok := vs.pkgs[path]; !ok {
+			vs.paths = append(vs.paths, path)
+		}
+		vs.pkgs[path] = pkg.Types
+		vs.complete[path] = true
+	}
+	return nil
+}
+
+func (vs *symbolValidator) addPackage(pkg *types.Package) {
+	path := nonVendoredPkgPath(pkg.Path())
+	if _, ok := vs.pkgs[path]; ok {
+		return
+	}
+	vs.pkgs[path] = pkg
+	vs.paths = append(vs.paths, path)
+	for _, imp := range pkg.Imports() {
+		vs.addPackage(imp)
+	}
+}
+
+// validate checks an entry and returns a description of the first problem
+// found and, if possible, a corrected entry.
+func (vs *symbolValidator) validate(entry string) (msg, suggestion string) {
+	r, err := parseSymbol(entry)
+	if err != nil {
+		return err.Error(), ""
+	}
+	prefix := ""
+	if r.negated {
+		prefix = "!"
+	}
+	rest := strings.TrimPrefix(entry, prefix)[len(r.name):]
+	fix := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return prefix + name + rest
+	}
+
+	if isSymbolGlob(r.name) {
+		pkgPath, _, _, ok := splitSymbolName(r.name)
+		if p, found := strings.CutSuffix(r.name, "/..."); found {
+			pkgPath, ok = p, true
+		}
+		if !ok || strings.Contains(pkgPath, "*") || strings.Contains(pkgPath, "...") {
+			return "", ""
+		}
+		if re := anyMajorRegexp(pkgPath); re != nil {
+			if !slices.ContainsFunc(vs.paths, re.MatchString) {
+				return fmt.Sprintf("unknown package %s", pkgPath), ""
+			}
+			return "", ""
+		}
+		if _, ok := vs.pkgs[pkgPath]; !ok {
+			p := closestName(pkgPath, vs.paths)
+			if p == "" {
+				return fmt.Sprintf("unknown package %s", pkgPath), ""
+			}
+			return fmt.Sprintf("unknown package %s", pkgPath), fix(p + r.name[len(pkgPath):])
+		}
+		return "", ""
+	}
+
+	fn, msg, name := vs.resolveName(r.name)
+	if msg != "" {
+		return msg, fix(name)
+	}
+	if fn == nil {
+		// The package could not be loaded completely.
+		return "", ""
+	}
+	for _, arg := range append(r.args[:len(r.args):len(r.args)], r.methodArgs...) {
+		if msg, corrected := vs.validateArg(arg); msg != "" {
+			if corrected != "" {
+				corrected = strings.Replace(entry, arg, corrected, 1)
+			}
+			return msg, corrected
+		}
+	}
+	if r.method == "" {
+		return "", ""
+	}
+
+	// The method is called on the result of fn.
+	results := fn.Type().(*types.Signature).Results()
+	if results.Len() == 0 {
+		return fmt.Sprintf("%s has no result to call %s on", r.name, r.method), ""
+	}
+	t := results.At(0).Type()
+	if obj, _, _ := types.LookupFieldOrMethod(t, true, fn.Pkg(), r.method); obj == nil {
+		msg := fmt.Sprintf("result of %s has no method %s", r.name, r.method)
+		if m := closestName(r.method, methodNames(t)); m != "" {
+			i := strings.LastIndex(entry, "."+r.method)
+			return msg, entry[:i+1] + m + entry[i+1+len(r.method):]
+		}
+		return msg, ""
+	}
+	return "", ""
+}
+
+// resolveName resolves the name of a function or method. If it does not
+// exist, resolveName returns a description of the problem and the closest
+// correct name, if any.
+func (vs *symbolValidator) resolveName(name string) (fn *types.Func, msg, suggestion string) {
+	pkgPath, recv, funcName, ok := splitSymbolName(name)
+	if !ok {
+		return nil, "missing package path", ""
+	}
+
+	var scope *types.Scope
+	if pkgPath == "" {
+		scope = types.Universe
+	} else {
+		pkg, ok := vs.pkgs[pkgPath]
+		if !ok {
+			return nil, fmt.Sprintf("unknown package %s", pkgPath), vs.fixPackage(name, pkgPath)
+		}
+		if !vs.complete[pkgPath] {
+			return nil, "", ""
+		}
+		scope = pkg.Scope()
+	}
+
+	if recv == "" {
+		obj := scope.Lookup(funcName)
+		if obj == nil {
+			msg := fmt.Sprintf("package %s has no function %s", pkgPath, funcName)
+			if n := closestName(funcName, scopeNames(scope, isFunc)); n != "" {
+				return nil, msg, pkgPath + "." + n
+			}
+			return nil, msg, ""
+		}
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			return nil, fmt.Sprintf("%s is not a function", name), ""
+		}
+		return fn, "", ""
+	}
+
+	ptr := strings.HasPrefix(recv, "*")
+	typeName, typeArgs := strings.TrimPrefix(recv, "*"), ""
+	if i := strings.IndexByte(typeName, '['); i >= 0 {
+		typeName, typeArgs = typeName[:i], typeName[i:]
+	}
+	qualified := typeName
+	if pkgPath != "" {
+		qualified = pkgPath + "." + typeName
+	}
+	// recvName rebuilds the name with a different receiver or method.
+	recvName := func(ptr bool, typeName, method string) string {
+		star := ""
+		if ptr {
+			star = "*"
+		}
+		if pkgPath != "" {
+			typeName = pkgPath + "." + typeName
+		}
+		return "(" + star + typeName + typeArgs + ")." + method
+	}
+
+	tn, ok := scope.Lookup(typeName).(*types.TypeName)
+	if !ok {
+		msg := fmt.Sprintf("unknown type %s", qualified)
+		if n := closestName(typeName, scopeNames(scope, isType)); n != "" {
+			return nil, msg, recvName(ptr, n, funcName)
+		}
+		return nil, msg, ""
+	}
+
+	t := tn.Type()
+	if types.IsInterface(t) && ptr {
+		return nil, fmt.Sprintf("%s is an interface; its methods have no pointer receiver", qualified), recvName(false, typeName, funcName)
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(t, true, tn.Pkg(), funcName)
+	fn, ok = obj.(*types.Func)
+	if !ok {
+		msg := fmt.Sprintf("type %s has no method %s", qualified, funcName)
+		if n := closestName(funcName, methodNames(t)); n != "" {
+			return nil, msg, recvName(ptr, typeName, n)
+		}
+		return nil, msg, ""
+	}
+	if types.IsInterface(t) {
+		return fn, "", ""
+	}
+
+	// The name of a method includes the receiver it is declared with.
+	recvType := fn.Type().(*types.Signature).Recv().Type()
+	_, hasPtrRecv := recvType.(*types.Pointer)
+	if named, ok := types.Unalias(derefType(recvType)).(*types.Named); ok && named.Origin().Obj() != tn {
+		return nil, fmt.Sprintf("method %s is promoted from %s", funcName, named.Origin().Obj().Name()), fn.FullName()
+	}
+	switch {
+	case hasPtrRecv && !ptr:
+		return nil, fmt.Sprintf("method %s has a pointer receiver", funcName), recvName(true, typeName, funcName)
+	case !hasPtrRecv && ptr:
+		return nil, fmt.Sprintf("method %s has a value receiver", funcName), recvName(false, typeName, funcName)
+	}
+	return fn, "", ""
+}
+
+// qualifiedIdent matches the qualified identifiers in an argument pattern.
+var qualifiedIdent = regexp.MustCompile(`([\w.~-]+(?:/[\w.~-]+)*)\.(\w+)`)
+
+// validateArg checks an argument pattern. If it is invalid, validateArg
+// returns a description of the problem and, if possible, a corrected
+// pattern.
+func (vs *symbolValidator) validateArg(arg string) (msg, corrected string) {
+	if arg == "*" || arg == "_" || strings.Contains(arg, AnyMajor) {
+		return "", ""
+	}
+	expr, err := parser.ParseExpr(arg)
+	if err == nil {
+		switch e := expr.(type) {
+		case *ast.BasicLit:
+			return "", ""
+		case *ast.UnaryExpr:
+			if _, ok := e.X.(*ast.BasicLit); ok {
+				return "", ""
+			}
+		case *ast.Ident:
+			switch types.Universe.Lookup(e.Name).(type) {
+			case *types.TypeName, *types.Const, *types.Nil:
+				return "", ""
+			}
+			msg := fmt.Sprintf("argument pattern %s is not a type, variable or constant", arg)
+			return msg, closestName(arg, scopeNames(types.Universe, isArg))
+		}
+	}
+
+	// A qualified identifier may refer to a variable, a constant or a type;
+	// the qualified identifiers in other patterns must be types.
+	loc := qualifiedIdent.FindStringSubmatchIndex(arg)
+	whole := loc != nil && loc[0] == len(arg)-len(strings.TrimLeft(arg, "*")) && loc[1] == len(arg)
+	for _, m := range qualifiedIdent.FindAllStringSubmatch(arg, -1) {
+		pkgPath, name := m[1], m[2]
+		pkg, ok := vs.pkgs[pkgPath]
+		if !ok {
+			msg := fmt.Sprintf("unknown package %s in argument pattern %s", pkgPath, arg)
+			if p := closestName(pkgPath, vs.paths); p != "" {
+				return msg, strings.Replace(arg, m[0], p+"."+name, 1)
+			}
+			return msg, ""
+		}
+		if !vs.complete[pkgPath] {
+			continue
+		}
+		switch pkg.Scope().Lookup(name).(type) {
+		case *types.TypeName:
+			continue
+		case *types.Var, *types.Const:
+			if whole && !strings.HasPrefix(arg, "*") {
+				continue
+			}
+		}
+		msg := fmt.Sprintf("argument pattern %s: %s.%s is not a type", arg, pkgPath, name)
+		filter := isType
+		if whole && !strings.HasPrefix(arg, "*") {
+			msg = fmt.Sprintf("argument pattern %s is not a type, variable or constant", arg)
+			filter = isArg
+		}
+		if n := closestName(name, scopeNames(pkg.Scope(), filter)); n != "" {
+			return msg, strings.Replace(arg, m[0], pkgPath+"."+n, 1)
+		}
+		return msg, ""
+	}
+	return "", ""
+}
+
+// fixPackage returns name with the package path replaced by the closest
+// known one, or the empty string if there is none.
+func (vs *symbolValidator) fixPackage(name, pkgPath string) string {
+	p := closestName(pkgPath, vs.paths)
+	if p == "" {
+		return ""
+	}
+	return strings.Replace(name, pkgPath+".", p+".", 1)
+}
+
+// splitSymbolName splits the name of an exclusion entry into the package
+// path, the receiver type, if any, and the function or method name.
+func splitSymbolName(name string) (pkgPath, recv, funcName string, ok bool) {
+	if strings.HasPrefix(name, "(") {
+		end := closingParen(name)
+		if end < 0 || !strings.HasPrefix(name[end+1:], ".") {
+			return "", "", "", false
+		}
+		recv, funcName = name[1:end], name[end+2:]
+		typ := strings.TrimPrefix(recv, "*")
+		if i := strings.IndexByte(typ, '['); i >= 0 {
+			typ = typ[:i]
+		}
+		i := strings.LastIndexByte(typ, '.')
+		if i < 0 {
+			// A predeclared type, such as error.
+			return "", recv, funcName, true
+		}
+		pkgPath = typ[:i]
+		recv = strings.Replace(recv, pkgPath+".", "", 1)
+		return pkgPath, recv, funcName, true
+	}
+	slash := strings.LastIndexByte(name, '/')
+	i := strings.LastIndexByte(name, '.')
+	if i <= slash {
+		return "", "", "", false
+	}
+	return name[:i], "", name[i+1:], true
+}
+
+// derefType returns the element type of a pointer type, or t itself.
+func derefType(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+func isFunc(obj types.Object) bool {
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+func isType(obj types.Object) bool {
+	_, ok := obj.(*types.TypeName)
+	return ok
+}
+
+func isArg(obj types.Object) bool {
+	switch obj.(type) {
+	case *types.TypeName, *types.Var, *types.Const, *types.Nil:
+		return true
+	}
+	return false
+}
+
+// scopeNames returns the names of the objects in scope for which filter
+// returns true.
+func scopeNames(scope *types.Scope, filter func(types.Object) bool) []string {
+	var names []string
+	for _, name := range scope.Names() {
+		if filter(scope.Lookup(name)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// methodNames returns the names of the methods of t and *t.
+func methodNames(t types.Type) []string {
+	if _, ok := t.(*types.Pointer); !ok && !types.IsInterface(t) {
+		t = types.NewPointer(t)
+	}
+	ms := types.NewMethodSet(t)
+	names := make([]string, 0, ms.Len())
+	for i := range ms.Len() {
+		names = append(names, ms.At(i).Obj().Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closestName returns the candidate closest to name in edit distance, if it
+// is close enough to be a likely misspelling. Differences in case count as
+// less than other edits.
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", 0
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		d := 2 * editDistance(strings.ToLower(name), strings.ToLower(c))
+		if strings.EqualFold(c, name) {
+			d = 1
+		}
+		if best == "" || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" || bestDist > 2*max(1, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,21 @@
+package errcheck
+
+import (
+	"testing"
+)
+
+func TestClosestName(t *testing.T) {
+	candidates := []string{"Client", "Cookie", "Request", "Response", "ResponseWriter"}
+	cases := map[string]string{
+		"client":  "Client",
+		"Requset": "Request",
+		"Resonse": "Response",
+		"Writer":  "",
+		"X":       "",
+	}
+	for name, want := range cases {
+		if got := closestName(name, candidates); got != want {
+			t.Errorf("closestName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -2,7 +2,10 @@ module github.com/kisielk/errcheck
 
 go 1.25.0
 
-require golang.org/x/tools v0.44.0
+require (
+	golang.org/x/tools v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	golang.org/x/mod v0.35.0 // indirect
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+	"strings"
+
+	"github.com/kisielk/errcheck/errcheck"
+	"golang.org/x/tools/go/packages"
+)
+
+// jsonVersion is the version of the schema of the -format=json output. It
+// changes only when a field is removed or changes its meaning; fields may be
+// added within a version.
+const jsonVersion = 1
+
+// jsonReport is the document printed with -format=json.
+type jsonReport struct {
+	Version int         `json:"version"`
+	Config  jsonConfig  `json:"config"`
+	Errors  []jsonError `json:"errors"`
+	Summary jsonSummary `json:"summary"`
+}
+
+// jsonError is an unchecked error. Lines and columns start at 1, and columns
+// count bytes. The end position is that of the character after the expression
+// or identifier the error was found at.
+type jsonError struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end-line"`
+	EndColumn int    `json:"end-column"`
+	Package   string `json:"package"`
+	Kind      string `json:"kind"`
+
+	// Function and Selector are the FuncName and SelectorName of the
+	// error, or empty if it was not found at a call.
+	Function string `json:"function"`
+	Selector string `json:"selector"`
+
+	// Source is the line of source code of the error, without leading and
+	// trailing white space.
+	Source string `json:"source"`
+
+	// Related is the shadowed declaration of a shadowed error, or null.
+	Related *jsonPosition `json:"related"`
+
+	// Scope lists the names of the scopes of the configuration file that
+	// applied to the error, in order.
+	Scope []string `json:"scope"`
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// jsonConfig is the effective configuration, after the configuration file
+// and the command-line flags have been applied. Its keys are those of the
+// configuration file, except for the output settings and for exclude and
+// excludeonly, whose entries Symbols lists along with the others. The checks
+// are reported as enabled or not.
+type jsonConfig struct {
+	File            string                 `json:"file"`
+	Tags            []string               `json:"tags"`
+	Mod             string                 `json:"mod"`
+	Blank           bool                   `json:"blank"`
+	Asserts         bool                   `json:"asserts"`
+	Unread          bool                   `json:"unread"`
+	Shadow          bool                   `json:"shadow"`
+	Receive         bool                   `json:"receive"`
+	Nolint          bool                   `json:"nolint"`
+	IgnoreTests     bool                   `json:"ignoretests"`
+	IgnoreGenerated bool                   `json:"ignoregenerated"`
+	GeneratedMarker []string               `json:"generated-marker"`
+	GeneratedPath   []string               `json:"generated-path"`
+	IgnorePkg       []string               `json:"ignorepkg"`
+	Ignore          map[string]string      `json:"ignore"`
+	ExcludePath     []string               `json:"exclude-path"`
+	Symbols         []string               `json:"symbols"`
+	Scopes          []errcheck.ConfigScope `json:"scopes"`
+}
+
+// jsonSummary sums up the run. Packages counts the test variants of a
+// package once, and Kinds holds the number of errors of every kind, including
+// those that were not found.
+type jsonSummary struct {
+	Packages int            `json:"packages"`
+	Errors   int            `json:"errors"`
+	Kinds    map[string]int `json:"kinds"`
+}
+
+// kinds lists the kinds of errors that are counted in the summary.
+var kinds = []errcheck.Kind{
+	errcheck.KindUnchecked,
+	errcheck.KindBlank,
+	errcheck.KindAssert,
+	errcheck.KindUnread,
+	errcheck.KindShadowed,
+	errcheck.KindReceive,
+	errcheck.KindDirective,
+}
+
+// reportJSON prints the unchecked errors of e, found in pkgs by checker, to w
+// as a jsonReport.
+func reportJSON(w io.Writer, checker *errcheck.Checker, pkgs []*packages.Package, e errcheck.Result) error {
+	relative := relativeTo()
+	position := func(pos token.Position) *jsonPosition {
+		return &jsonPosition{
+			File:   relative(token.Position{Filename: pos.Filename}),
+			Line:   pos.Line,
+			Column: pos.Column,
+		}
+	}
+
+	report := jsonReport{
+		Version: jsonVersion,
+		Config:  effectiveConfig(checker),
+		Errors:  []jsonError{},
+		Summary: jsonSummary{Errors: len(e.UncheckedErrors), Kinds: map[string]int{}},
+	}
+	for _, err := range e.UncheckedErrors {
+		je := jsonError{
+			File:      relative(token.Position{Filename: err.Pos.Filename}),
+			Line:      err.Pos.Line,
+			Column:    err.Pos.Column,
+			EndLine:   err.End.Line,
+			EndColumn: err.End.Column,
+			Package:   err.Package,
+			Kind:      err.Kind.String(),
+			Function:  err.FuncName,
+			Selector:  err.SelectorName,
+			Source:    err.Line,
+			Scope:     scopeNames(err.Scope),
+		}
+		if err.Related.IsValid() {
+			je.Related = position(err.Related)
+		}
+		report.Errors = append(report.Errors, je)
+	}
+
+	for _, k := range kinds {
+		report.Summary.Kinds[k.String()] = 0
+	}
+	for _, err := range e.UncheckedErrors {
+		report.Summary.Kinds[err.Kind.String()]++
+	}
+	paths := map[string]bool{}
+	for _, pkg := range pkgs {
+		// The generated main packages of tests are not counted.
+		if pkg.Name == "main" && strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		paths[pkg.PkgPath] = true
+	}
+	report.Summary.Packages = len(paths)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(report)
+}
+
+// effectiveConfig returns the configuration of checker.
+func effectiveConfig(checker *errcheck.Checker) jsonConfig {
+	ex := &checker.Exclusions
+	cfg := jsonConfig{
+		File:            configPath,
+		Tags:            orEmpty(checker.Tags),
+		Mod:             checker.Mod,
+		Blank:           !ex.BlankAssignments,
+		Asserts:         !ex.TypeAssertions,
+		Unread:          ex.CheckUnread,
+		Shadow:          ex.CheckShadowed,
+		Receive:         ex.CheckReceives,
+		Nolint:          ex.NolintDirectives,
+		IgnoreTests:     ex.TestFiles,
+		IgnoreGenerated: ex.GeneratedFiles,
+		GeneratedMarker: orEmpty(ex.GeneratedMarkers),
+		GeneratedPath:   orEmpty(ex.GeneratedPaths),
+		IgnorePkg:       orEmpty(ex.Packages),
+		Ignore:          map[string]string{},
+		ExcludePath:     orEmpty(ex.Paths),
+		Symbols:         orEmpty(ex.Symbols),
+		Scopes:          []errcheck.ConfigScope{},
+	}
+	for pkg, re := range ex.SymbolRegexpsByPackage {
+		cfg.Ignore[pkg] = re.String()
+	}
+	for _, sc := range ex.Scopes {
+		cfg.Scopes = append(cfg.Scopes, errcheck.ConfigScope{
+			Name:     sc.Name,
+			Packages: sc.Packages,
+			Files:    sc.Files,
+			Symbols:  orEmpty(sc.Symbols),
+			Blank:    enabled(sc.BlankAssignments),
+			Asserts:  enabled(sc.TypeAssertions),
+			Unread:   sc.CheckUnread,
+			Shadow:   sc.CheckShadowed,
+			Receive:  sc.CheckReceives,
+			Nolint:   sc.NolintDirectives,
+		})
+	}
+	return cfg
+}
+
+// enabled turns a setting that ignores a check into one that enables it.
+func enabled(ignore *bool) *bool {
+	if ignore == nil {
+		return nil
+	}
+	v := !*ignore
+	return &v
+}
+
+// scopeNames splits the Scope of an UncheckedError into the names of the
+// scopes.
+func scopeNames(scope string) []string {
+	if scope == "" {
+		return []string{}
+	}
+	return strings.Split(scope, ", ")
+}
+
+// orEmpty returns list, or an empty list if it is nil, so that it is encoded
+// as [] rather than null.
+func orEmpty(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
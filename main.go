@@ -3,10 +3,15 @@ package main
 import (
 	"flag"
 	"fmt"
+	"go/token"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -26,6 +31,45 @@ type ignoreFlag map[string]*regexp.Regexp
 var (
 	abspath bool
 	verbose bool
+
+	// reportUnused enables reporting of exclusions and directives that
+	// did not suppress anything. Entries of the built-in exclude list are
+	// only reported if reportUnusedDefaults is also set.
+	reportUnused         bool
+	reportUnusedDefaults bool
+
+	// reportExcluded enables reporting of the calls that were excluded from
+	// checking, along with the exclusion responsible.
+	reportExcluded bool
+
+	// validate enables checking that the exclude entries refer to existing
+	// functions, methods and types.
+	validate bool
+
+	// migrateFile is the path of the exclude file to write the calls
+	// excluded by -ignore and -ignorepkg to, instead of reporting errors.
+	migrateFile string
+
+	// discard enables rewriting the unchecked errors into explicit discards
+	// marked with //errcheck:legacy directives, instead of reporting them.
+	discard bool
+
+	// fix enables applying the fixes suggested for unchecked errors, instead
+	// of reporting them.
+	fix bool
+
+	// showDiff prints the changes of rewrites as a unified diff instead of
+	// writing them to the files.
+	showDiff bool
+
+	// outputFormat is the output format: text or json.
+	outputFormat string
+
+	// configPath is the path of the configuration file in use, if any.
+	configPath string
+
+	// excludeEntries are the entries read from the -exclude file.
+	excludeEntries []errcheck.ExcludeEntry
 )
 
 func (f ignoreFlag) String() string {
@@ -41,24 +85,12 @@ func (f ignoreFlag) String() string {
 }
 
 func (f ignoreFlag) Set(s string) error {
-	if s == "" {
-		return nil
+	regexps, err := errcheck.ParseIgnore(s)
+	if err != nil {
+		return err
 	}
-	for _, pair := range strings.Split(s, ",") {
-		colonIndex := strings.Index(pair, ":")
-		var pkg, re string
-		if colonIndex == -1 {
-			pkg = ""
-			re = pair
-		} else {
-			pkg = pair[:colonIndex]
-			re = pair[colonIndex+1:]
-		}
-		regex, err := regexp.Compile(re)
-		if err != nil {
-			return err
-		}
-		f[pkg] = regex
+	for pkg, re := range regexps {
+		f[pkg] = re
 	}
 	return nil
 }
@@ -84,25 +116,248 @@ func (f *tagsFlag) Set(s string) error {
 	return nil
 }
 
-func reportResult(e errcheck.Result) {
+// pathsFlag is a flag that may be repeated to give a list of path patterns.
+type pathsFlag []string
+
+func (f *pathsFlag) String() string {
+	return fmt.Sprintf("%q", strings.Join(*f, ","))
+}
+
+func (f *pathsFlag) Set(s string) error {
+	if s != "" {
+		*f = append(*f, s)
+	}
+	return nil
+}
+
+// relativeTo returns a function that formats positions relative to the
+// working directory, unless absolute paths were requested.
+func relativeTo() func(token.Position) string {
 	wd, err := os.Getwd()
 	if err != nil {
 		wd = ""
 	}
-	for _, uncheckedError := range e.UncheckedErrors {
-		pos := uncheckedError.Pos.String()
+	return func(pos token.Position) string {
+		s := pos.String()
 		if !abspath {
-			newPos, err := filepath.Rel(wd, pos)
+			newPos, err := filepath.Rel(wd, s)
 			if err == nil {
-				pos = newPos
+				s = newPos
 			}
 		}
+		return s
+	}
+}
+
+// reportResult prints the unchecked errors to w.
+func reportResult(w io.Writer, e errcheck.Result) {
+	relative := relativeTo()
+	for _, uncheckedError := range e.UncheckedErrors {
+		pos := relative(uncheckedError.Pos)
+
+		line := uncheckedError.Line
+		if uncheckedError.Kind == errcheck.KindShadowed {
+			line += "\t(shadows " + relative(uncheckedError.Related) + ")"
+		}
+
+		if verbose && uncheckedError.Scope != "" {
+			line += "\t(scope " + uncheckedError.Scope + ")"
+		}
 
 		if verbose && uncheckedError.FuncName != "" {
-			fmt.Printf("%s:\t%s\t%s\n", pos, uncheckedError.FuncName, uncheckedError.Line)
+			fmt.Fprintf(w, "%s:\t%s\t%s\n", pos, uncheckedError.FuncName, line)
 		} else {
-			fmt.Printf("%s:\t%s\n", pos, uncheckedError.Line)
+			fmt.Fprintf(w, "%s:\t%s\n", pos, line)
+		}
+	}
+}
+
+// reportUnusedExclusions prints the exclusions of checker and the inline
+// directives that did not suppress any error. It returns the number of
+// entries reported.
+func reportUnusedExclusions(w io.Writer, checker *errcheck.Checker, usage errcheck.Usage) int {
+	relative := relativeTo()
+	n := 0
+	report := func(where, what string) {
+		fmt.Fprintf(w, "%s:\t%s\n", where, what)
+		n++
+	}
+
+	if reportUnusedDefaults {
+		for _, sym := range errcheck.DefaultExcludedSymbols {
+			if !usage.Symbols[sym] && slices.Contains(checker.Exclusions.Symbols, sym) {
+				report("default excludes", "unused exclude "+sym)
+			}
+		}
+	}
+	for _, e := range excludeEntries {
+		if !usage.Symbols[e.Symbol] {
+			report(relative(e.Pos), "unused exclude "+e.Symbol)
+		}
+	}
+	unusedIgnores(checker, usage, report)
+	for _, d := range usage.Directives {
+		if !d.Used {
+			report(relative(d.Pos), "unused directive "+d.Text)
+		}
+	}
+	return n
+}
+
+// unusedIgnores calls report for each -ignorepkg package and -ignore pattern
+// that did not exclude any call.
+func unusedIgnores(checker *errcheck.Checker, usage errcheck.Usage, report func(where, what string)) {
+	for _, pkg := range checker.Exclusions.Packages {
+		if !usage.Packages[pkg] {
+			report("-ignorepkg", "unused package "+pkg)
+		}
+	}
+	pkgs := make([]string, 0, len(checker.Exclusions.SymbolRegexpsByPackage))
+	for pkg := range checker.Exclusions.SymbolRegexpsByPackage {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		if usage.Regexps[pkg] || slices.Contains(checker.Exclusions.Packages, pkg) {
+			continue
+		}
+		report("-ignore", "unused pattern "+ignorePattern(pkg, checker.Exclusions.SymbolRegexpsByPackage[pkg].String()))
+	}
+}
+
+// ignorePattern formats a pattern of -ignore.
+func ignorePattern(pkg, re string) string {
+	if pkg == "" {
+		return re
+	}
+	return pkg + ":" + re
+}
+
+// migrateIgnore writes an exclude file to path that lists the functions and
+// methods whose calls the -ignore and -ignorepkg patterns excluded, so that
+// the patterns can be replaced by the file. parseFlags sets up the checker so
+// that the patterns are the only exclusions and every call is checked.
+// Patterns that did not exclude anything are reported on standard error. If path is "-", the file is
+// written to standard output.
+func migrateIgnore(checker *errcheck.Checker, usage errcheck.Usage, path string) int {
+	relative := relativeTo()
+	sections := map[string][]string{}
+	for _, c := range usage.Excluded {
+		var pattern string
+		switch {
+		case c.Symbol != "":
+			continue
+		case c.Regexp != "":
+			pattern = "-ignore " + ignorePattern(c.Package, c.Regexp)
+		default:
+			pattern = "-ignorepkg " + c.Package
+		}
+		if c.FuncName == "" {
+			fmt.Fprintf(os.Stderr, "%s:\tcall excluded by %s cannot be named by an exclude entry\n", relative(c.Pos), pattern)
+			continue
+		}
+		if !slices.Contains(sections[pattern], c.FuncName) {
+			sections[pattern] = append(sections[pattern], c.FuncName)
+		}
+	}
+
+	patterns := make([]string, 0, len(sections))
+	for pattern := range sections {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var b strings.Builder
+	b.WriteString("// Migrated by errcheck -migrateignore from the -ignore and -ignorepkg patterns.\n")
+	for _, pattern := range patterns {
+		fmt.Fprintf(&b, "\n// %s\n", pattern)
+		symbols := sections[pattern]
+		sort.Strings(symbols)
+		for _, sym := range symbols {
+			b.WriteString(sym + "\n")
+		}
+	}
+
+	unusedIgnores(checker, usage, func(where, what string) {
+		fmt.Fprintf(os.Stderr, "%s:\t%s\n", where, what)
+	})
+
+	var err error
+	if path == "-" {
+		_, err = os.Stdout.WriteString(b.String())
+	} else {
+		err = os.WriteFile(path, []byte(b.String()), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write exclude file: %v\n", err)
+		return exitFatalError
+	}
+	return exitCodeOk
+}
+
+// reportInvalidExclusions prints the entries of the exclude file and the
+// configuration file that do not refer to existing functions, methods or
+// types of the checked packages and their dependencies. It returns the
+// number of entries reported.
+func reportInvalidExclusions(w io.Writer, c *errcheck.Checker, pkgs []*packages.Package) (int, error) {
+	relative := relativeTo()
+	symbols := make([]string, len(excludeEntries))
+	for i, e := range excludeEntries {
+		symbols[i] = e.Symbol
+	}
+
+	// The problems are in the order of the entries.
+	problems, err := c.ValidateSymbols(symbols, pkgs)
+	if err != nil {
+		return 0, err
+	}
+	i := 0
+	for _, p := range problems {
+		for symbols[i] != p.Symbol {
+			i++
+		}
+		fmt.Fprintf(w, "%s:\tinvalid exclude %s\n", relative(excludeEntries[i].Pos), p)
+		i++
+	}
+	return len(problems), nil
+}
+
+// reportExcludedCalls prints the calls that were excluded from checking and
+// the exclusions that excluded them, with the position and reason of the
+// exclude file entries.
+func reportExcludedCalls(w io.Writer, usage errcheck.Usage) {
+	relative := relativeTo()
+	entries := map[string]errcheck.ExcludeEntry{}
+	for _, e := range excludeEntries {
+		if _, ok := entries[e.Symbol]; !ok {
+			entries[e.Symbol] = e
+		}
+	}
+
+	for _, c := range usage.Excluded {
+		var by string
+		switch {
+		case c.Symbol != "":
+			by = "exclude " + c.Symbol
+			if e, ok := entries[c.Symbol]; ok {
+				by += "\t(" + relative(e.Pos)
+				if e.Reason != "" {
+					by += ": " + e.Reason
+				}
+				by += ")"
+			} else if slices.Contains(errcheck.DefaultExcludedSymbols, c.Symbol) {
+				by += "\t(default excludes)"
+			}
+		case c.Regexp != "":
+			prefix := ""
+			if c.Package != "" {
+				prefix = c.Package + ":"
+			}
+			by = "-ignore " + prefix + c.Regexp
+		default:
+			by = "-ignorepkg " + c.Package
 		}
+		fmt.Fprintf(w, "%s:\t%s excluded by %s\n", relative(c.Pos), c.FuncName, by)
 	}
 }
 
@@ -119,28 +374,121 @@ func mainCmd(args []string) int {
 		return rc
 	}
 
-	result, err := checkPaths(&checker, paths...)
+	pkgs, result, err := checkPaths(&checker, paths...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: failed to check packages: %s\n", err)
 		return exitFatalError
 	}
+	if migrateFile != "" {
+		return migrateIgnore(&checker, result.Usage, migrateFile)
+	}
+	if fix || discard || showDiff {
+		result, err = rewriteErrors(pkgs, result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to rewrite files: %s\n", err)
+			return exitFatalError
+		}
+	}
+	// With -diff, standard output holds the patch. With -format=json, which
+	// cannot be combined with -diff, it holds the JSON document only. The
+	// other reports then go to standard error.
+	out := os.Stdout
+	if showDiff {
+		out = os.Stderr
+	}
+	reports := out
+	if outputFormat == "json" {
+		reports = os.Stderr
+	}
+
+	rc = exitCodeOk
 	if len(result.UncheckedErrors) > 0 {
-		reportResult(result)
-		return exitUncheckedError
+		rc = exitUncheckedError
 	}
-	return exitCodeOk
+	if outputFormat == "json" {
+		if err := reportJSON(out, &checker, pkgs, result); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to write JSON output: %s\n", err)
+			return exitFatalError
+		}
+	} else {
+		reportResult(out, result)
+	}
+	if reportExcluded {
+		reportExcludedCalls(reports, result.Usage)
+	}
+	if validate {
+		n, err := reportInvalidExclusions(reports, &checker, pkgs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to validate exclusions: %s\n", err)
+			return exitFatalError
+		}
+		if n > 0 {
+			rc = exitUncheckedError
+		}
+	}
+	if reportUnused && reportUnusedExclusions(reports, &checker, result.Usage) > 0 {
+		rc = exitUncheckedError
+	}
+	return rc
 }
 
-func checkPaths(c *errcheck.Checker, paths ...string) (errcheck.Result, error) {
-	pkgs, err := c.LoadPackages(paths...)
+// rewriteErrors applies the fixes that Analyzer suggests for the unchecked
+// errors of result with -fix, and rewrites them into explicit discards with
+// -discard, or prints the changes with -diff. It returns the errors that
+// could not be rewritten.
+//
+// Each error is fixed in the first package that contains its file, so that
+// files that the test variant of a package shares with it are fixed once.
+func rewriteErrors(pkgs []*packages.Package, result errcheck.Result) (errcheck.Result, error) {
+	var fixes []errcheck.Fix
+	rest := result.UncheckedErrors
+	apply := func(fixer func(*packages.Package, []errcheck.UncheckedError) ([]errcheck.Fix, []errcheck.UncheckedError, error)) error {
+		for _, pkg := range pkgs {
+			f, r, err := fixer(pkg, rest)
+			if err != nil {
+				return err
+			}
+			fixes = append(fixes, f...)
+			rest = r
+		}
+		return nil
+	}
+	if fix || !discard {
+		if err := apply(errcheck.SuggestedFixes); err != nil {
+			return errcheck.Result{}, err
+		}
+	}
+	if discard {
+		if err := apply(errcheck.DiscardFixes); err != nil {
+			return errcheck.Result{}, err
+		}
+	}
+
+	changes, conflicts, err := rewriteFiles(fixes)
 	if err != nil {
 		return errcheck.Result{}, err
 	}
+	for _, c := range conflicts {
+		rest = append(rest, c.Err)
+	}
+	if showDiff {
+		printDiffs(changes)
+	} else if err := writeChanges(changes); err != nil {
+		return errcheck.Result{}, err
+	}
+	return errcheck.Result{UncheckedErrors: rest, Usage: result.Usage}.Unique(), nil
+}
+
+func checkPaths(c *errcheck.Checker, paths ...string) ([]*packages.Package, errcheck.Result, error) {
+	pkgs, err := c.LoadPackages(paths...)
+	if err != nil {
+		return nil, errcheck.Result{}, err
+	}
 	// Check for errors in the initial packages.
 	work := make(chan *packages.Package, len(pkgs))
 	for _, pkg := range pkgs {
 		if len(pkg.Errors) > 0 {
-			return errcheck.Result{}, fmt.Errorf("errors while loading package %s: %v", pkg.ID, pkg.Errors)
+			return nil, errcheck.Result{}, fmt.Errorf("errors while loading package %s: %v", pkg.ID, pkg.Errors)
 		}
 		work <- pkg
 	}
@@ -165,24 +513,37 @@ func checkPaths(c *errcheck.Checker, paths ...string) (errcheck.Result, error) {
 	}
 
 	wg.Wait()
-	return result.Unique(), nil
+	return pkgs, result.Unique(), nil
 }
 
 func parseFlags(checker *errcheck.Checker, args []string) ([]string, int) {
 	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
 
-	var checkAsserts, checkBlanks bool
+	var checkAsserts, checkBlanks, checkUnread, checkShadow, checkReceives bool
 
 	flags.BoolVar(&checkBlanks, "blank", false, "if true, check for errors assigned to blank identifier")
 	flags.BoolVar(&checkAsserts, "asserts", false, "if true, check for ignored type assertion results")
+	flags.BoolVar(&checkUnread, "unread", false, "if true, check for errors assigned to variables that are overwritten or never read")
+	flags.BoolVar(&checkShadow, "shadow", false, "if true, check for error variables that shadow an outer error that has not been checked")
+	flags.BoolVar(&checkReceives, "receive", false, "if true, check for errors received from channels and discarded")
 	flags.BoolVar(&checker.Exclusions.TestFiles, "ignoretests", false, "if true, checking of _test.go files is disabled")
 	flags.BoolVar(&checker.Exclusions.GeneratedFiles, "ignoregenerated", false, "if true, checking of files with generated code is disabled")
+	flags.BoolVar(&checker.Exclusions.NolintDirectives, "nolint", false, "if true, honor //nolint:errcheck and //lint:ignore errcheck comments")
 	flags.BoolVar(&verbose, "verbose", false, "produce more verbose logging")
 
 	flags.BoolVar(&abspath, "abspath", false, "print absolute paths to files")
+	flags.BoolVar(&reportUnused, "reportunused", false, "if true, report exclusions and inline directives that did not suppress any error")
+	flags.BoolVar(&reportUnusedDefaults, "reportunuseddefaults", false, "if true, -reportunused also reports entries of the built-in exclude list")
+	flags.BoolVar(&validate, "validate", false, "if true, report exclude entries that do not refer to existing functions, methods or types")
+	flags.BoolVar(&reportExcluded, "reportexcluded", false, "if true, report the calls that were excluded from checking and the exclusions responsible")
 
 	tags := tagsFlag{}
 	flags.Var(&tags, "tags", "comma or space-separated list of build tags to include")
+	var excludePaths pathsFlag
+	flags.Var(&excludePaths, "exclude-path", "glob pattern of files to exclude from checking, such as third_party/ or '**/*_mock.go'; may be repeated")
+	var generatedMarkers, generatedPaths pathsFlag
+	flags.Var(&generatedMarkers, "generated-marker", "additional text that marks files as generated for -ignoregenerated in a comment before the package clause; may be repeated")
+	flags.Var(&generatedPaths, "generated-path", "glob pattern of generated files for -ignoregenerated, such as '**/*.pb.go'; may be repeated")
 	ignorePkg := flags.String("ignorepkg", "", "comma-separated list of package paths to ignore")
 	ignore := ignoreFlag(map[string]*regexp.Regexp{})
 	flags.Var(ignore, "ignore", "[deprecated] comma-separated list of pairs of the form pkg:regex\n"+
@@ -196,27 +557,99 @@ func parseFlags(checker *errcheck.Checker, args []string) ([]string, int) {
 
 	flags.StringVar(&checker.Mod, "mod", "", "module download mode to use: readonly or vendor. See 'go help modules' for more.")
 
+	flags.StringVar(&migrateFile, "migrateignore", "", "Path of an exclude file to write the functions excluded by -ignore and -ignorepkg to, or - for standard output.\n"+
+		"            Other exclusions are disabled and blank assignments are checked while the file is written. No errors are reported.")
+
+	flags.BoolVar(&discard, "discard", false, "if true, rewrite unchecked errors of call, defer and go statements into explicit discards\n"+
+		"            marked with //errcheck:legacy comments, and report only the others")
+	flags.BoolVar(&fix, "fix", false, "if true, apply the fixes suggested for unchecked errors, and report only the errors without one")
+	flags.BoolVar(&showDiff, "diff", false, "if true, print the changes of -fix or -discard as a unified diff instead of writing them.\n"+
+		"            Implies -fix unless -discard is given.")
+
+	flags.StringVar(&outputFormat, "format", "text", "output format: text, or json for a JSON document described in the README")
+
+	var configFile string
+	flags.StringVar(&configFile, "config", "", "Path to a configuration file. By default, "+strings.Join(errcheck.ConfigFileNames, ", ")+
+		"\n            is looked up from the working directory to the module root.")
+
 	if err := flags.Parse(args[1:]); err != nil {
 		return nil, exitFatalError
 	}
 
+	if configFile == "" {
+		found, err := errcheck.FindConfig(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not look up configuration file: %v\n", err)
+			return nil, exitFatalError
+		}
+		configFile = found
+	}
+	configPath = ""
+	var configSymbols []string
+	if configFile != "" {
+		cfg, err := errcheck.LoadConfig(configFile)
+		if err == nil {
+			err = applyConfig(flags, cfg, ignore)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read configuration file: %v\n", err)
+			return nil, exitFatalError
+		}
+		logf("using configuration file %s", configFile)
+		configPath = configFile
+		configSymbols = cfg.Symbols
+		checker.Exclusions.Scopes = cfg.ExclusionScopes()
+	}
+
+	if outputFormat != "text" && outputFormat != "json" {
+		fmt.Fprintf(os.Stderr, "invalid value %q for -format: must be text or json\n", outputFormat)
+		return nil, exitFatalError
+	}
+	if outputFormat == "json" && showDiff {
+		// Both would be printed to standard output.
+		fmt.Fprintln(os.Stderr, "-format=json cannot be used with -diff")
+		return nil, exitFatalError
+	}
+
 	checker.Exclusions.BlankAssignments = !checkBlanks
 	checker.Exclusions.TypeAssertions = !checkAsserts
+	checker.Exclusions.CheckUnread = checkUnread
+	checker.Exclusions.CheckShadowed = checkShadow
+	checker.Exclusions.CheckReceives = checkReceives
 
 	if !excludeOnly {
 		checker.Exclusions.Symbols = append(checker.Exclusions.Symbols, errcheck.DefaultExcludedSymbols...)
 	}
 
+	excludeEntries = nil
 	if excludeFile != "" {
-		excludes, err := errcheck.ReadExcludes(excludeFile)
+		entries, err := errcheck.ReadExcludeEntries(excludeFile)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Could not read exclude file: %v\n", err)
 			return nil, exitFatalError
 		}
-		checker.Exclusions.Symbols = append(checker.Exclusions.Symbols, excludes...)
+		excludeEntries = entries
+		for _, e := range entries {
+			checker.Exclusions.Symbols = append(checker.Exclusions.Symbols, e.Symbol)
+		}
+	}
+	checker.Exclusions.Symbols = append(checker.Exclusions.Symbols, configSymbols...)
+
+	// Entries of the configuration file are reported without a line.
+	for _, sc := range checker.Exclusions.Scopes {
+		configSymbols = append(configSymbols[:len(configSymbols):len(configSymbols)], sc.Symbols...)
+	}
+	for _, sym := range configSymbols {
+		excludeEntries = append(excludeEntries, errcheck.ExcludeEntry{
+			Symbol: sym,
+			Pos:    token.Position{Filename: configFile},
+		})
 	}
 
 	checker.Tags = tags
+	checker.Exclusions.Paths = excludePaths
+	checker.Exclusions.GeneratedMarkers = generatedMarkers
+	checker.Exclusions.GeneratedPaths = generatedPaths
 	for _, pkg := range strings.Split(*ignorePkg, ",") {
 		if pkg != "" {
 			checker.Exclusions.Packages = append(checker.Exclusions.Packages, pkg)
@@ -225,6 +658,16 @@ func parseFlags(checker *errcheck.Checker, args []string) ([]string, int) {
 
 	checker.Exclusions.SymbolRegexpsByPackage = ignore
 
+	if migrateFile != "" {
+		// Calls are attributed to -ignore and -ignorepkg only if no exclude
+		// entry matches them first, and blank assignments and type
+		// assertions are only looked at if they are checked.
+		checker.Exclusions.Symbols = nil
+		checker.Exclusions.Scopes = nil
+		checker.Exclusions.BlankAssignments = false
+		checker.Exclusions.TypeAssertions = false
+	}
+
 	paths := flags.Args()
 	if len(paths) == 0 {
 		paths = []string{"."}
@@ -233,6 +676,90 @@ func parseFlags(checker *errcheck.Checker, args []string) ([]string, int) {
 	return paths, exitCodeOk
 }
 
+// applyConfig sets the flags that the configuration file sets, unless they
+// have been set on the command line.
+func applyConfig(flags *flag.FlagSet, cfg *errcheck.Config, ignore ignoreFlag) error {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	values := map[string]string{}
+	for name, b := range map[string]*bool{
+		"blank":                cfg.Blank,
+		"asserts":              cfg.Asserts,
+		"unread":               cfg.Unread,
+		"shadow":               cfg.Shadow,
+		"receive":              cfg.Receive,
+		"nolint":               cfg.Nolint,
+		"ignoretests":          cfg.IgnoreTests,
+		"ignoregenerated":      cfg.IgnoreGenerated,
+		"excludeonly":          cfg.ExcludeOnly,
+		"abspath":              cfg.Abspath,
+		"verbose":              cfg.Verbose,
+		"reportunused":         cfg.ReportUnused,
+		"reportunuseddefaults": cfg.ReportUnusedDefaults,
+		"reportexcluded":       cfg.ReportExcluded,
+		"validate":             cfg.Validate,
+	} {
+		if b != nil {
+			values[name] = strconv.FormatBool(*b)
+		}
+	}
+	if len(cfg.Tags) > 0 {
+		values["tags"] = strings.Join(cfg.Tags, ",")
+	}
+	if len(cfg.IgnorePkg) > 0 {
+		values["ignorepkg"] = strings.Join(cfg.IgnorePkg, ",")
+	}
+	if cfg.Exclude != "" {
+		values["exclude"] = cfg.Exclude
+	}
+	if cfg.Mod != "" {
+		values["mod"] = cfg.Mod
+	}
+	if cfg.Format != "" {
+		values["format"] = cfg.Format
+	}
+
+	for name, value := range values {
+		if set[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %s: %v", cfg.Path, name, err)
+		}
+	}
+
+	for name, list := range map[string][]string{
+		"exclude-path":     cfg.ExcludePath,
+		"generated-marker": cfg.GeneratedMarker,
+		"generated-path":   cfg.GeneratedPath,
+	} {
+		if set[name] {
+			continue
+		}
+		for _, value := range list {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("%s: %s: %v", cfg.Path, name, err)
+			}
+		}
+	}
+
+	// The regular expressions of -ignore may contain commas, so they are
+	// not passed through the flag.
+	if !set["ignore"] {
+		regexps, err := cfg.IgnoreRegexps()
+		if err != nil {
+			return err
+		}
+		for pkg, re := range regexps {
+			ignore[pkg] = re
+		}
+	}
+	return nil
+}
+
 func main() {
 	os.Exit(mainCmd(os.Args))
 }
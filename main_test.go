@@ -2,13 +2,19 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/kisielk/errcheck/errcheck"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
 )
 
 var dotStar = regexp.MustCompile(".*")
@@ -56,12 +62,48 @@ func TestMainFunc(t *testing.T) {
 		t.Errorf("Exit code is %d, expected %d", exitCode, exitUncheckedError)
 	}
 
-	expectUnchecked := 29
+	expectUnchecked := 45
 	if got := strings.Count(out, "UNCHECKED"); got != expectUnchecked {
 		t.Errorf("Got %d UNCHECKED errors, expected %d in:\n%s", got, expectUnchecked, out)
 	}
 }
 
+func TestPackageScope(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/sc\n\ngo 1.22\n",
+		"sub/sub.go":     "package sub\n\nimport \"os\"\n\nfunc F() {\n\tos.Remove(\"x\")\n}\n",
+		".errcheck.yaml": "scopes:\n  - packages: example.com/sc/sub/...\n    symbols: [os.Remove]\n",
+		"empty.yaml":     "",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	saveStdout := os.Stdout
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = saveStdout }()
+
+	if rc := mainCmd([]string{"errcheck", "./..."}); rc != exitCodeOk {
+		t.Errorf("errcheck with a package scope exited with %d, want %d", rc, exitCodeOk)
+	}
+	if rc := mainCmd([]string{"errcheck", "-config", "empty.yaml", "./..."}); rc != exitUncheckedError {
+		t.Errorf("errcheck without the scope exited with %d, want %d", rc, exitUncheckedError)
+	}
+}
+
 type parseTestCase struct {
 	args    []string
 	paths   []string
@@ -249,3 +291,541 @@ func TestParseFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFlagsConfig(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "cmd", "tool")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"go.mod":         "module example.com/m\n",
+		".errcheck.yaml": "blank: true\nasserts: true\ntags: [foo]\nignorepkg: [fmt]\nignore:\n  io: \"Copy|Read,Write\"\nexclude: excludes.txt\nsymbols: [io.Copy]\nexclude-path: [examples/, \"*_mock.go\"]\nformat: json\n",
+		"excludes.txt":   "os.ReadFile\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saveCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(nested); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(saveCwd) }()
+
+	var checker errcheck.Checker
+	_, rc := parseFlags(&checker, []string{"errcheck", "-asserts=false", "-tags", "bar", "-excludeonly"})
+	if rc != exitCodeOk {
+		t.Fatalf("parseFlags failed with %d", rc)
+	}
+	if checker.Exclusions.BlankAssignments {
+		t.Errorf("expected blank checks to be enabled by the configuration")
+	}
+	if !checker.Exclusions.TypeAssertions {
+		t.Errorf("expected -asserts=false to override the configuration")
+	}
+	if want := []string{"bar"}; !reflect.DeepEqual([]string(checker.Tags), want) {
+		t.Errorf("tags got %q want %q", checker.Tags, want)
+	}
+	if want := []string{"fmt"}; !reflect.DeepEqual(checker.Exclusions.Packages, want) {
+		t.Errorf("packages got %q want %q", checker.Exclusions.Packages, want)
+	}
+	if re := checker.Exclusions.SymbolRegexpsByPackage["io"]; re == nil || re.String() != "Copy|Read,Write" {
+		t.Errorf("ignore got %v", checker.Exclusions.SymbolRegexpsByPackage)
+	}
+	if want := []string{"os.ReadFile", "io.Copy"}; !reflect.DeepEqual(checker.Exclusions.Symbols, want) {
+		t.Errorf("symbols got %q want %q", checker.Exclusions.Symbols, want)
+	}
+	if outputFormat != "json" {
+		t.Errorf("format got %q want json", outputFormat)
+	}
+	// The patterns of the configuration are relative to its directory.
+	if want := []string{filepath.ToSlash(dir) + "/examples/", filepath.ToSlash(dir) + "/*_mock.go"}; !reflect.DeepEqual(checker.Exclusions.Paths, want) {
+		t.Errorf("paths got %q want %q", checker.Exclusions.Paths, want)
+	}
+
+	checker = errcheck.Checker{}
+	_, rc = parseFlags(&checker, []string{"errcheck", "-exclude-path", "third_party/", "-exclude-path", "gen/**"})
+	if rc != exitCodeOk {
+		t.Fatalf("parseFlags failed with %d", rc)
+	}
+	if want := []string{"third_party/", "gen/**"}; !reflect.DeepEqual(checker.Exclusions.Paths, want) {
+		t.Errorf("paths got %q want %q", checker.Exclusions.Paths, want)
+	}
+}
+
+func TestMigrateIgnore(t *testing.T) {
+	const testPackage = "github.com/kisielk/errcheck/testdata"
+	file := filepath.Join(t.TempDir(), "excludes.txt")
+
+	var checker errcheck.Checker
+	paths, rc := parseFlags(&checker, []string{"errcheck", "-ignore", "fmt:.*,io:[rR]ead", "-ignorepkg", "encoding/json", "-migrateignore", file, testPackage})
+	if rc != exitCodeOk {
+		t.Fatalf("parseFlags failed with %d", rc)
+	}
+	_, migrated, err := checkPaths(&checker, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc := migrateIgnore(&checker, migrated.Usage, file); rc != exitCodeOk {
+		t.Fatalf("migrateIgnore failed with %d", rc)
+	}
+
+	entries, err := errcheck.ReadExcludes(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fmt.Println is listed although the default exclude entries cover it.
+	for _, want := range []string{"fmt.Fprintf", "fmt.Println", "(*encoding/json.Encoder).Encode"} {
+		if !slices.Contains(entries, want) {
+			t.Errorf("missing entry %s in %q", want, entries)
+		}
+	}
+
+	// The migrated exclude file excludes the same calls as the patterns.
+	checker = errcheck.Checker{}
+	paths, rc = parseFlags(&checker, []string{"errcheck", "-ignore", "fmt:.*,io:[rR]ead", "-ignorepkg", "encoding/json", testPackage})
+	if rc != exitCodeOk {
+		t.Fatalf("parseFlags failed with %d", rc)
+	}
+	_, ignored, err := checkPaths(&checker, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker = errcheck.Checker{}
+	paths, rc = parseFlags(&checker, []string{"errcheck", "-exclude", file, testPackage})
+	if rc != exitCodeOk {
+		t.Fatalf("parseFlags failed with %d", rc)
+	}
+	_, excluded, err := checkPaths(&checker, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ignored.UncheckedErrors, excluded.UncheckedErrors) {
+		t.Errorf("got %d errors with the migrated exclude file, want %d", len(excluded.UncheckedErrors), len(ignored.UncheckedErrors))
+	}
+}
+
+func TestAnalyzerParity(t *testing.T) {
+	const testPackage = "github.com/kisielk/errcheck/testdata"
+	excludes := filepath.Join(t.TempDir(), "excludes.txt")
+	if err := os.WriteFile(excludes, []byte("io.Copy\n(*bytes.Buffer).*\n!fmt.Fprintf(os.Stderr)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		cli      []string
+		analyzer map[string]string
+	}{
+		{name: "default"},
+		{
+			name:     "checks",
+			cli:      []string{"-blank", "-asserts", "-unread", "-shadow", "-receive", "-nolint"},
+			analyzer: map[string]string{"blank": "true", "assert": "true", "unread": "true", "shadow": "true", "receive": "true", "nolint": "true"},
+		},
+		{
+			name:     "ignorepkg",
+			cli:      []string{"-ignorepkg", "os,io"},
+			analyzer: map[string]string{"ignorepkg": "os,io"},
+		},
+		{
+			name:     "ignore",
+			cli:      []string{"-ignore", "fmt:a^,os:Remove|Close,Read"},
+			analyzer: map[string]string{"ignore": "fmt:a^,os:Remove|Close,Read"},
+		},
+		{
+			name:     "ignoretests",
+			cli:      []string{"-ignoretests"},
+			analyzer: map[string]string{"ignoretests": "true"},
+		},
+		{
+			name:     "exclude",
+			cli:      []string{"-exclude", excludes, "-excludeonly"},
+			analyzer: map[string]string{"exclude": excludes, "excludeonly": "true"},
+		},
+		{
+			name:     "paths",
+			cli:      []string{"-exclude-path", "testdata/main*.go", "-ignoregenerated", "-generated-path", "testdata/results.go"},
+			analyzer: map[string]string{"exclude-path": "testdata/main*.go", "ignoregenerated": "true", "generated-path": "testdata/results.go"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var checker errcheck.Checker
+			paths, rc := parseFlags(&checker, append(append([]string{"errcheck"}, c.cli...), testPackage))
+			if rc != exitCodeOk {
+				t.Fatalf("parseFlags failed with %d", rc)
+			}
+			_, result, err := checkPaths(&checker, paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, e := range result.UncheckedErrors {
+				want = append(want, e.Pos.String())
+			}
+			slices.Sort(want)
+			want = slices.Compact(want)
+
+			// Every case has an analyzer of its own, so that the flags
+			// set by the others do not apply.
+			a := errcheck.NewFlagAnalyzer()
+			for name, value := range c.analyzer {
+				if err := a.Flags.Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Drivers such as go vet always load the test files, which the
+			// analyzer skips with ignoretests.
+			got := runAnalyzer(t, a, testPackage)
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("analyzer reported %d errors, CLI %d\nanalyzer: %q\nCLI: %q", len(got), len(want), got, want)
+			}
+		})
+	}
+}
+
+// runAnalyzer runs the analyzer a on the packages matched by pattern,
+// including their tests, and returns the sorted positions of its diagnostics.
+func runAnalyzer(t *testing.T, a *analysis.Analyzer, pattern string) []string {
+	t.Helper()
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var positions []string
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			t.Fatalf("errors while loading package %s: %v", pkg.ID, pkg.Errors)
+		}
+		pass := &analysis.Pass{
+			Analyzer:  a,
+			Fset:      pkg.Fset,
+			Files:     pkg.Syntax,
+			Pkg:       pkg.Types,
+			TypesInfo: pkg.TypesInfo,
+			Report: func(d analysis.Diagnostic) {
+				positions = append(positions, pkg.Fset.Position(d.Pos).String())
+			},
+		}
+		if _, err := a.Run(pass); err != nil {
+			t.Fatal(err)
+		}
+	}
+	slices.Sort(positions)
+	return slices.Compact(positions)
+}
+
+func TestDiscard(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/legacy\n\ngo 1.22\n",
+		"a.go": `package legacy
+
+import "io"
+
+func f() error        { return nil }
+func g() (int, error) { return 0, nil }
+
+func run(w io.WriteCloser, r io.ReadCloser) {
+	f()
+	g() // best effort
+	defer w.Close()
+	go f()
+	defer r.Close()
+	r = nil
+	_ = g
+}
+`,
+		"a_test.go": `package legacy
+
+import "testing"
+
+func TestF(t *testing.T) { f() }
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	if rc := mainCmd([]string{"errcheck", "-discard", "./..."}); rc != exitCodeOk {
+		t.Fatalf("errcheck -discard exited with %d", rc)
+	}
+	want := map[string]string{
+		"a.go": `package legacy
+
+import "io"
+
+func f() error        { return nil }
+func g() (int, error) { return 0, nil }
+
+func run(w io.WriteCloser, r io.ReadCloser) {
+	_ = f()                          //errcheck:legacy
+	_, _ = g()                       //errcheck:legacy // best effort
+	defer func() { _ = w.Close() }() //errcheck:legacy
+	go func() { _ = f() }()          //errcheck:legacy
+	defer r.Close()                  //errcheck:legacy
+	r = nil
+	_ = g
+}
+`,
+		"a_test.go": `package legacy
+
+import "testing"
+
+func TestF(t *testing.T) {
+	//errcheck:legacy
+	_ = f()
+}
+`,
+	}
+	for name, src := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != src {
+			t.Errorf("%s after -discard:\n%s\nwant:\n%s", name, got, src)
+		}
+	}
+
+	for _, args := range [][]string{{"errcheck", "./..."}, {"errcheck", "-blank", "./..."}} {
+		if rc := mainCmd(args); rc != exitCodeOk {
+			t.Errorf("%q exited with %d after -discard", args, rc)
+		}
+	}
+
+	// A directive above the if statement would suppress the findings in its
+	// body too, so the call in its header is left to be reported.
+	header := `package legacy
+
+func h() {
+	if f(); true {
+	}
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "h.go"), []byte(header), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rc := mainCmd([]string{"errcheck", "-discard", "./..."}); rc != exitUncheckedError {
+		t.Errorf("errcheck -discard exited with %d, want %d", rc, exitUncheckedError)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "h.go")); err != nil {
+		t.Fatal(err)
+	} else if string(got) != header {
+		t.Errorf("h.go after -discard:\n%s\nwant:\n%s", got, header)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl"
+	want := `--- a/x.go
++++ b/x.go
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,3 +9,4 @@
+ i
+ j
+ k
++l
+\ No newline at end of file
+`
+	if got := unifiedDiff("a/x.go", "b/x.go", []byte(old), []byte(new)); got != want {
+		t.Errorf("unifiedDiff:\n%s\nwant:\n%s", got, want)
+	}
+	if got := unifiedDiff("a/x.go", "b/x.go", []byte(old), []byte(old)); got != "" {
+		t.Errorf("unifiedDiff of equal texts = %q, want empty", got)
+	}
+}
+
+func TestFix(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/fix\n\ngo 1.22\n",
+		"a.go": `package fix
+
+func f() error { return nil }
+
+func run() error {
+	f()
+	return nil
+}
+
+func noResult() {
+	f()
+}
+`,
+		// The test variant of the package declares err at package level,
+		// which would give its fix another name.
+		"a_test.go": `package fix
+
+var err error
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	// The error in noResult has no fix and remains.
+	if rc := mainCmd([]string{"errcheck", "-fix", "./..."}); rc != exitUncheckedError {
+		t.Fatalf("errcheck -fix exited with %d, want %d", rc, exitUncheckedError)
+	}
+	want := `package fix
+
+func f() error { return nil }
+
+func run() error {
+	if err := f(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func noResult() {
+	f()
+}
+`
+	got, err := os.ReadFile(filepath.Join(dir, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("a.go after -fix:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRewriteFiles(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.go")
+	src := "package a\n\nfunc f() {\n\tg()\n\th()\n}\n"
+	if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := strings.Index(src, "g()")
+	h := strings.Index(src, "h()")
+	insert := func(offset int, text string) errcheck.Edit {
+		return errcheck.Edit{Filename: name, Start: offset, End: offset, NewText: []byte(text)}
+	}
+	fixes := []errcheck.Fix{
+		{Message: "h", Edits: []errcheck.Edit{insert(h, "_ = ")}},
+		{Message: "g", Edits: []errcheck.Edit{insert(g, "_ = ")}},
+		// An identical fix, as found in a test variant, is applied once.
+		{Message: "g", Edits: []errcheck.Edit{insert(g, "_ = ")}},
+		// An overlapping fix conflicts with the one applied before.
+		{Message: "g2", Edits: []errcheck.Edit{{Filename: name, Start: g, End: g + 3, NewText: []byte("_ = g()")}}},
+	}
+	changes, conflicts, err := rewriteFiles(fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Message != "g2" {
+		t.Errorf("got conflicts %v, want the g2 fix", conflicts)
+	}
+	want := "package a\n\nfunc f() {\n\t_ = g()\n\t_ = h()\n}\n"
+	if len(changes) != 1 || string(changes[0].new) != want {
+		t.Errorf("got changes %q, want %q", changes, want)
+	}
+
+	// A fix that leaves the file impossible to format is left out, and the
+	// others are still applied.
+	fixes = []errcheck.Fix{
+		{Message: "g", Edits: []errcheck.Edit{insert(g, "_ = ")}},
+		{Message: "h", Edits: []errcheck.Edit{insert(h, "_ = (")}},
+	}
+	changes, conflicts, err = rewriteFiles(fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Message != "h" {
+		t.Errorf("got conflicts %v, want the h fix", conflicts)
+	}
+	want = "package a\n\nfunc f() {\n\t_ = g()\n\th()\n}\n"
+	if len(changes) != 1 || string(changes[0].new) != want {
+		t.Errorf("got changes %q, want %q", changes, want)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	saveStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	bufChannel := make(chan []byte)
+	go func() {
+		buf, _ := io.ReadAll(r)
+		bufChannel <- buf
+	}()
+	exitCode := mainCmd([]string{"errcheck", "-format=json", "-shadow", "-ignorepkg", "encoding/json", "github.com/kisielk/errcheck/testdata"})
+	w.Close()
+	os.Stdout = saveStdout
+	out := <-bufChannel
+
+	if exitCode != exitUncheckedError {
+		t.Errorf("Exit code is %d, expected %d", exitCode, exitUncheckedError)
+	}
+	var report jsonReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if report.Version != jsonVersion {
+		t.Errorf("got version %d, want %d", report.Version, jsonVersion)
+	}
+	if !report.Config.Shadow || report.Config.Blank || !slices.Equal(report.Config.IgnorePkg, []string{"encoding/json"}) {
+		t.Errorf("unexpected configuration %+v", report.Config)
+	}
+	if report.Summary.Packages != 1 || report.Summary.Errors != len(report.Errors) || report.Summary.Kinds["shadowed"] == 0 {
+		t.Errorf("unexpected summary %+v", report.Summary)
+	}
+
+	want := jsonError{
+		File:      filepath.Join("testdata", "main.go"),
+		Line:      153,
+		Column:    13,
+		EndLine:   153,
+		EndColumn: 24,
+		Package:   "github.com/kisielk/errcheck/testdata",
+		Kind:      "unchecked",
+		Function:  "os.ReadFile",
+		Selector:  "os.ReadFile",
+		Source:    `os.ReadFile("main.go") // UNCHECKED`,
+		Scope:     []string{},
+	}
+	if !slices.ContainsFunc(report.Errors, func(e jsonError) bool { return reflect.DeepEqual(e, want) }) {
+		t.Errorf("missing error %+v", want)
+	}
+	for _, e := range report.Errors {
+		if e.Kind == "shadowed" && e.Related == nil {
+			t.Errorf("shadowed error without related position: %+v", e)
+		}
+	}
+
+	// The patch printed by -diff would be mixed with the JSON document.
+	if rc := mainCmd([]string{"errcheck", "-format=json", "-diff", "github.com/kisielk/errcheck/testdata"}); rc != exitFatalError {
+		t.Errorf("-format=json -diff exited with %d, want %d", rc, exitFatalError)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kisielk/errcheck/errcheck"
+)
+
+// fileChange is the old and new contents of a rewritten file.
+type fileChange struct {
+	name     string
+	old, new []byte
+}
+
+// rewriteFiles applies the fixes to the files they refer to and formats the
+// results with go/format. Fixes that are identical, as found in the test
+// variants of a package, are applied once. A fix with an edit that overlaps
+// one of a fix applied before is not applied, nor is one that leaves its file
+// impossible to format; the fixes that were not are returned along with the
+// changes.
+func rewriteFiles(fixes []errcheck.Fix) ([]fileChange, []errcheck.Fix, error) {
+	byFile := map[string][]errcheck.Fix{}
+	var names []string
+	for _, fix := range fixes {
+		if len(fix.Edits) == 0 {
+			continue
+		}
+		name := fix.Edits[0].Filename
+		if _, ok := byFile[name]; !ok {
+			names = append(names, name)
+		}
+		byFile[name] = append(byFile[name], fix)
+	}
+	sort.Strings(names)
+
+	var changes []fileChange
+	var conflicts []errcheck.Fix
+	for _, name := range names {
+		fixes := byFile[name]
+		sort.SliceStable(fixes, func(i, j int) bool {
+			return fixes[i].Edits[0].Start < fixes[j].Edits[0].Start
+		})
+		var applied []errcheck.Fix
+		var edits []errcheck.Edit
+		for _, fix := range fixes {
+			if slices.ContainsFunc(applied, func(f errcheck.Fix) bool { return sameEdits(f.Edits, fix.Edits) }) {
+				continue
+			}
+			if conflicting(edits, fix.Edits) {
+				conflicts = append(conflicts, fix)
+				continue
+			}
+			applied = append(applied, fix)
+			edits = append(edits, fix.Edits...)
+		}
+
+		old, err := os.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		new, err := rewrite(old, applied)
+		if err != nil {
+			// Find the fixes that break the file by applying them one at a
+			// time, and leave them out.
+			var kept []errcheck.Fix
+			new = old
+			for _, fix := range applied {
+				src, err := rewrite(old, append(slices.Clip(kept), fix))
+				if err != nil {
+					conflicts = append(conflicts, fix)
+					continue
+				}
+				kept, new = append(kept, fix), src
+			}
+		}
+		if !bytes.Equal(old, new) {
+			changes = append(changes, fileChange{name: name, old: old, new: new})
+		}
+	}
+	return changes, conflicts, nil
+}
+
+// rewrite returns src with the edits of the fixes, which must not conflict,
+// applied and formatted with go/format. Edits that several fixes share are
+// applied once.
+func rewrite(src []byte, fixes []errcheck.Fix) ([]byte, error) {
+	var edits []errcheck.Edit
+	for _, fix := range fixes {
+		for _, e := range fix.Edits {
+			if !slices.ContainsFunc(edits, func(applied errcheck.Edit) bool { return sameEdit(applied, e) }) {
+				edits = append(edits, e)
+			}
+		}
+	}
+	src, err := applyEdits(src, edits)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("formatting rewritten file: %v", err)
+	}
+	return formatted, nil
+}
+
+func sameEdit(a, b errcheck.Edit) bool {
+	return a.Filename == b.Filename && a.Start == b.Start && a.End == b.End && bytes.Equal(a.NewText, b.NewText)
+}
+
+func sameEdits(a, b []errcheck.Edit) bool {
+	return slices.EqualFunc(a, b, sameEdit)
+}
+
+// conflicting reports whether any of edits overlaps one of applied, other
+// than an identical edit. Insertions at the same offset conflict since their
+// order is not defined.
+func conflicting(applied, edits []errcheck.Edit) bool {
+	for _, a := range applied {
+		for _, e := range edits {
+			if sameEdit(a, e) {
+				continue
+			}
+			if a.Start < e.End && e.Start < a.End || a.Start == e.Start {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyEdits returns src with the edits, which must not overlap, applied.
+func applyEdits(src []byte, edits []errcheck.Edit) ([]byte, error) {
+	edits = slices.Clone(edits)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].Start != edits[j].Start {
+			return edits[i].Start < edits[j].Start
+		}
+		return edits[i].End < edits[j].End
+	})
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End < e.Start || e.End > len(src) {
+			return nil, fmt.Errorf("invalid edit at offset %d", e.Start)
+		}
+		buf.Write(src[last:e.Start])
+		buf.Write(e.NewText)
+		last = e.End
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), nil
+}
+
+// writeChanges writes the new contents of the changed files.
+func writeChanges(changes []fileChange) error {
+	for _, c := range changes {
+		info, err := os.Stat(c.name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(c.name, c.new, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDiffs prints the changes as a unified diff that can be applied with
+// patch -p1 or git apply.
+func printDiffs(changes []fileChange) {
+	wd, _ := os.Getwd()
+	for _, c := range changes {
+		name := c.name
+		if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		name = filepath.ToSlash(name)
+		fmt.Print(unifiedDiff("a/"+name, "b/"+name, c.old, c.new))
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type sink struct{}
+
+const flushNone = 0
+
+func (sink) Flush(mode int) error { return nil }
+
+func excludedByArguments(w http.ResponseWriter, r io.Reader) {
+	io.Copy(io.Discard, r)                     // EXCLUDED
+	io.Copy(w, r)                              // UNCHECKED
+	io.Copy(io.Discard, strings.NewReader("")) // EXCLUDED
+
+	fmt.Fprintf(w, "hello") // EXCLUDED
+
+	json.NewEncoder(w).Encode(nil)         // EXCLUDED
+	json.NewEncoder(os.Stdout).Encode(nil) // UNCHECKED
+
+	var s sink
+	s.Flush(0)         // EXCLUDED
+	s.Flush(flushNone) // EXCLUDED
+	s.Flush(1)         // UNCHECKED
+}
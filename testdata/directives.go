@@ -0,0 +1,26 @@
+package main
+
+func ignoredByDirectives() {
+	a() //errcheck:ignore the result does not matter here
+
+	//errcheck:ignore a whole statement can be ignored
+	if true {
+		a()
+		b()
+	}
+
+	//errcheck:ignore multi-line calls are ignored as a whole
+	customError(
+	)
+
+	/*DIRECTIVE*/ //errcheck:ignore
+	a() // UNCHECKED
+
+	c() //errcheck:ignore stale, c does not return an error
+
+	_ = a()                    //errcheck:legacy
+	defer func() { _ = a() }() //errcheck:legacy
+
+	a() //nolint:errcheck
+	a() //lint:ignore errcheck the result does not matter here
+}
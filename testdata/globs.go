@@ -0,0 +1,16 @@
+package main
+
+type logger struct{}
+
+func (*logger) Write(p []byte) (int, error)       { return len(p), nil }
+func (*logger) WriteString(s string) (int, error) { return len(s), nil }
+func (*logger) Read(p []byte) (int, error)        { return 0, nil }
+func (logger) WriteByte(c byte) error             { return nil }
+
+func excludedByGlob() {
+	l := &logger{}
+	l.Write(nil)          // EXCLUDED
+	l.WriteString("")     // EXCLUDED
+	l.Read(nil)           // UNCHECKED
+	logger{}.WriteByte(0) // UNCHECKED
+}
@@ -0,0 +1,45 @@
+package main
+
+import "iter"
+
+func rows() iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {}
+}
+
+type store struct{}
+
+func (store) excludedRows() iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {}
+}
+
+func names() iter.Seq2[string, int] {
+	return func(yield func(string, int) bool) {}
+}
+
+func rangeOverIterators() {
+	for range rows() { // UNCHECKED
+	}
+	for r := range rows() { // UNCHECKED
+		_ = r
+	}
+	for r, _ := range rows() { // BLANK
+		_ = r
+	}
+	for r, err := range rows() {
+		_, _ = r, err
+	}
+
+	seq := rows()
+	for r := range seq { // UNCHECKED
+		_ = r
+	}
+
+	var st store
+	for r := range st.excludedRows() {
+		_ = r
+	}
+
+	for n := range names() {
+		_ = n
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+type errChan chan error
+
+func receiveErrors(errc chan error, named errChan, done chan struct{}) {
+	<-errc          // RECEIVE
+	(<-errc)        // RECEIVE
+	<-named         // RECEIVE
+	_ = <-errc      // RECEIVE
+	_, ok := <-errc // RECEIVE
+	_ = ok
+
+	select {
+	case <-errc: // RECEIVE
+	case _ = <-errc: // RECEIVE
+	case err := <-errc:
+		_ = err
+	case <-done:
+	}
+
+	if err := <-errc; err != nil {
+		return
+	}
+	<-done
+}
@@ -0,0 +1,50 @@
+package main
+
+// AliasError is an alias rather than a defined type.
+type AliasError = error
+
+func aliasError() AliasError {
+	return nil
+}
+
+func aliasErrorTuple() (int, AliasError) {
+	return 0, nil
+}
+
+// temporaryError returns an unnamed interface that embeds error.
+func temporaryError() interface {
+	error
+	Temporary() bool
+} {
+	return nil
+}
+
+func genericError[E error](f func() E) {
+	f()     // UNCHECKED
+	_ = f() // BLANK
+}
+
+func genericErrorTuple[E interface {
+	error
+	Temporary() bool
+}](f func() (int, E)) {
+	f()        // UNCHECKED
+	_, _ = f() // BLANK
+}
+
+func genericPointerError[E interface{ *MyPointerError }](f func() E) {
+	f() // UNCHECKED
+}
+
+func genericNonError[T any](f func() T) {
+	f()
+}
+
+func errorResults() {
+	aliasError()             // UNCHECKED
+	_ = aliasError()         // BLANK
+	aliasErrorTuple()        // UNCHECKED
+	_, _ = aliasErrorTuple() // BLANK
+	temporaryError()         // UNCHECKED
+	_ = temporaryError()     // BLANK
+}
@@ -0,0 +1,10 @@
+package main
+
+import "os"
+
+func scoped() {
+	os.Remove("scoped") // UNCHECKED
+
+	var s sink
+	_ = s.Flush(1) // BLANK
+}
@@ -0,0 +1,65 @@
+package main
+
+func shadowedInIf() error {
+	err := a()                            // UNREAD
+	if err := customError(); err != nil { // SHADOWED
+		return err
+	}
+	err = a()
+	return err
+}
+
+func shadowedInBlock(cond bool) (int, error) {
+	n, err := b()
+	if cond {
+		n, err := b() // SHADOWED
+		return n, err
+	}
+	return n, err
+}
+
+func shadowedByVar(cond bool) error {
+	err := a() // UNREAD
+	for cond {
+		var err error = customError() // SHADOWED
+		if err != nil {
+			return err
+		}
+	}
+	err = a()
+	return err
+}
+
+func notShadowedAfterCheck() error {
+	err := a()
+	if err != nil {
+		return err
+	}
+	if err := a(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// The outer error is returned after the inner one has been checked.
+func notShadowedReadLater(cond bool) error {
+	err := a()
+	if err := customError(); err != nil {
+		return err
+	}
+	for cond {
+		var err error = customError()
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func notShadowedByNonError() error {
+	err := a()
+	if err := c(); err > 0 {
+		return nil
+	}
+	return err
+}
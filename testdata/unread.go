@@ -0,0 +1,104 @@
+package main
+
+func unreadOverwritten() error {
+	err := a() // UNREAD
+	err = a()
+	return err
+}
+
+func unreadOverwrittenInBranches(cond bool) error {
+	err := a() // UNREAD
+	if cond {
+		err = a()
+	} else {
+		err = customError()
+	}
+	return err
+}
+
+func unreadMultipleResults() (int, error) {
+	n, err := b() // UNREAD
+	n, err = b()
+	return n, err
+}
+
+func unreadVarDecl() {
+	var err = a() // UNREAD
+	err = a()
+	_ = err
+}
+
+func unreadNamedResult() (err error) {
+	err = a() // UNREAD
+	return nil
+}
+
+func unreadInLoop() {
+	for i := 0; i < 3; i++ {
+		err := a() // UNREAD
+		err = a()
+		_ = err
+	}
+}
+
+func readBeforeOverwrite() error {
+	err := a()
+	if err != nil {
+		return err
+	}
+	err = a()
+	return err
+}
+
+func readOnSomePath(cond bool) error {
+	err := a()
+	if cond {
+		err = a()
+	}
+	return err
+}
+
+func readInSwitch(cond bool) error {
+	err := a()
+	switch {
+	case cond:
+		return err
+	}
+	err = a()
+	return err
+}
+
+func readInNextIteration() error {
+	var err error
+	for i := 0; i < 3; i++ {
+		if err != nil {
+			break
+		}
+		err = a()
+	}
+	return err
+}
+
+func readByBareReturn() (err error) {
+	err = a()
+	return
+}
+
+func readByClosure() error {
+	err := a()
+	defer func() {
+		_ = err
+	}()
+	err = a()
+	return err
+}
+
+func readWhenWrapped() error {
+	err := a()
+	err = wrap(err)
+	return err
+}
+
+func wrap(err error) error {
+	return err
+}